@@ -0,0 +1,38 @@
+package plywood
+
+import (
+	"context"
+)
+
+func ErrorIf(err error, msg ...interface{}) error { return logger.errorIf(err, msg) }
+func Assert(cond bool, msg ...interface{})        { logger.assert(cond, msg) }
+
+// ErrorIf logs msg at ERROR when err is not nil, with the message under
+// msg and the error under error, as Errorw(msg, "error", err) does.
+func (l *Log) ErrorIf(err error, msg ...interface{}) error { return l.errorIf(err, msg) }
+
+// Assert logs msg at FATAL and exits when cond is false.
+func (l *Log) Assert(cond bool, msg ...interface{}) { l.assert(cond, msg) }
+
+// errorIf is called by the ErrorIf functions.
+func (l *Log) errorIf(err error, msg []interface{}) error {
+	if err == nil || !l.Enabled(ERROR) {
+		return nil
+	}
+	m := keyValues(sprintln(msg), []interface{}{"error", err.Error()})
+	return l.send(context.Background(), ERROR, "", []interface{}{m}, false, "")
+}
+
+// assert is called by the Assert functions.
+func (l *Log) assert(cond bool, msg []interface{}) {
+	if cond {
+		return
+	}
+	if len(msg) == 0 {
+		msg = []interface{}{"assertion failed"}
+	}
+	if l.Enabled(FATAL) {
+		l.send(context.Background(), FATAL, "", msg, false, "")
+	}
+	l.exit()
+}
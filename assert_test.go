@@ -0,0 +1,54 @@
+package plywood
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorIf(t *testing.T) {
+	l := New("test", "testing", INFO)
+	mem := l.Capture()
+
+	if err := l.ErrorIf(nil, "not logged"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mem.Events()); n != 0 {
+		t.Fatalf("expected nothing logged for a nil error got %d", n)
+	}
+
+	l.ErrorIf(errors.New("disk full"), "save", "failed")
+	events := mem.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(events))
+	}
+	want := map[string]interface{}{"msg": "save failed", "error": "disk full"}
+	if events[0].Severity != "E" || !reflect.DeepEqual(events[0].Data, want) {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+}
+
+func TestAssert(t *testing.T) {
+	exits := 0
+	osExit = func(int) { exits++ }
+	defer func() { osExit = os.Exit }()
+
+	l := New("test", "testing", INFO)
+	mem := l.Capture()
+	l.Assert(true, "holds")
+	if n := len(mem.Events()); n != 0 || exits != 0 {
+		t.Fatalf("expected nothing for a true condition got %d events %d exits", n, exits)
+	}
+
+	l.Assert(1 > 2, "math broke")
+	events := mem.Events()
+	if len(events) != 1 || exits != 1 {
+		t.Fatalf("expected 1 event and exit got %d events %d exits", len(events), exits)
+	}
+	m, _ := events[0].Data.(map[string]interface{})
+	if events[0].Severity != "F" || !strings.Contains(m["str"].(string), "math broke") {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+}
@@ -0,0 +1,301 @@
+package plywood
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultQueueSize    = 1024
+	defaultQueueWorkers = 2
+)
+
+// dropWarnInterval is the least time between two warnings of dropped events.
+var dropWarnInterval = 10 * time.Second
+
+// OverflowPolicy is what an async loggly post does when the queue is full.
+type OverflowPolicy int
+
+const (
+	// Drop discards the newest event and counts it, see DroppedCount.
+	Drop OverflowPolicy = iota
+	// Block waits for room in the queue, pushing back on the caller.
+	Block
+)
+
+// asyncEvent is a log event waiting to be sent.
+type asyncEvent struct {
+	s        Sender
+	severity string
+	env      string
+	caller   string
+	data     interface{}
+}
+
+// asyncQueue is a bounded queue of log events drained by a fixed pool
+// of workers. The workers share the senders, a Loggly sender's
+// http.Client is safe for concurrent use and pools its connections.
+type asyncQueue struct {
+	events   chan asyncEvent
+	workers  int
+	dropped  uint64 // accessed atomically
+	once     sync.Once
+	wg       sync.WaitGroup
+	m        sync.RWMutex // guards closed and sends on events
+	closed   bool
+	pm       sync.Mutex // guards pending
+	pending  int        // events queued or being sent
+	drained  *sync.Cond // signaled when pending drops to zero
+	wm       sync.Mutex // guards lastWarn and warned
+	lastWarn time.Time  // when drops were last written to stderr
+	warned   uint64     // dropped count at the last warning
+}
+
+// newAsyncQueue creates a queue holding size events, its workers are
+// started with the first event.
+func newAsyncQueue(size, workers int) *asyncQueue {
+	if size < 1 {
+		size = defaultQueueSize
+	}
+	if workers < 1 {
+		workers = defaultQueueWorkers
+	}
+	q := &asyncQueue{
+		events:  make(chan asyncEvent, size),
+		workers: workers,
+	}
+	q.drained = sync.NewCond(&q.pm)
+	return q
+}
+
+// start runs the workers.
+func (q *asyncQueue) start() {
+	q.wg.Add(q.workers)
+	for i := 0; i < q.workers; i++ {
+		go q.work()
+	}
+}
+
+// work sends queued events until the queue is closed and drained.
+func (q *asyncQueue) work() {
+	defer q.wg.Done()
+	for ev := range q.events {
+		q.send(ev)
+		q.done()
+	}
+}
+
+// send sends ev. A panicking sender is written to stderr with the caller
+// of the event and the worker carries on, logging never takes the program
+// down from a worker.
+func (q *asyncQueue) send(ev asyncEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			diag("E " + ev.caller + " async send panic: " + fmt.Sprint(r) + "] \n")
+		}
+	}()
+	if err := sendCaller(ev.s, ev.severity, ev.env, ev.caller, ev.data); err != nil {
+		diag(err.Error() + "\n")
+	}
+}
+
+// add counts an event about to be queued.
+func (q *asyncQueue) add() {
+	q.pm.Lock()
+	q.pending++
+	q.pm.Unlock()
+}
+
+// done counts an event sent or dropped.
+func (q *asyncQueue) done() {
+	q.pm.Lock()
+	q.pending--
+	if q.pending == 0 {
+		q.drained.Broadcast()
+	}
+	q.pm.Unlock()
+}
+
+// flush waits until the queue is empty and its events are sent, the
+// workers keep running. Events queued meanwhile are waited for too.
+func (q *asyncQueue) flush() {
+	q.pm.Lock()
+	for q.pending > 0 {
+		q.drained.Wait()
+	}
+	q.pm.Unlock()
+}
+
+// enqueue adds ev to the queue. When the queue is full ev is dropped,
+// or with block the caller waits for room until ctx is done.
+func (q *asyncQueue) enqueue(ctx context.Context, ev asyncEvent, block bool) {
+	q.m.RLock()
+	defer q.m.RUnlock()
+	if q.closed {
+		q.drop()
+		return
+	}
+	q.once.Do(q.start)
+	q.add()
+	if block {
+		select {
+		case q.events <- ev:
+		case <-ctx.Done():
+			q.drop()
+			q.done()
+		}
+		return
+	}
+	select {
+	case q.events <- ev:
+	default:
+		q.drop()
+		q.done()
+	}
+}
+
+// drop counts a dropped event. At most every dropWarnInterval it writes
+// the number of events dropped since the last warning to stderr.
+func (q *asyncQueue) drop() {
+	n := atomic.AddUint64(&q.dropped, 1)
+	q.wm.Lock()
+	defer q.wm.Unlock()
+	now := time.Now()
+	if now.Sub(q.lastWarn) < dropWarnInterval {
+		return
+	}
+	q.lastWarn = now
+	q.warnDropped(n)
+}
+
+// warnDropped writes the events dropped since the last warning out of n.
+// The caller must hold q.wm.
+func (q *asyncQueue) warnDropped(n uint64) {
+	if n > q.warned {
+		diag("E async queue dropped " + strconv.FormatUint(n-q.warned, 10) + " events] \n")
+	}
+	q.warned = n
+}
+
+// close stops accepting events and waits for the workers to send
+// the backlog.
+func (q *asyncQueue) close() {
+	q.m.Lock()
+	if q.closed {
+		q.m.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.events)
+	q.m.Unlock()
+	q.wg.Wait()
+
+	q.wm.Lock()
+	q.warnDropped(atomic.LoadUint64(&q.dropped))
+	q.wm.Unlock()
+}
+
+// SetLogglyQueue sets the capacity and number of workers of the async loggly queue.
+func SetLogglyQueue(size, workers int) {
+	logger.SetLogglyQueue(size, workers)
+}
+
+// SetLogglyQueue sets the capacity and number of workers of the async
+// loggly queue. Events queued so far are sent before it is replaced, the
+// dropped count carries over.
+func (l *Log) SetLogglyQueue(size, workers int) {
+	l.mu.Lock()
+	old := l.logglyQueue
+	l.logglyQueue = newAsyncQueue(size, workers)
+	if old != nil {
+		l.logglyQueue.dropped = atomic.LoadUint64(&old.dropped)
+		l.logglyQueue.warned = l.logglyQueue.dropped
+	}
+	l.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+}
+
+// SetAsyncOverflowPolicy sets what the global logger's async loggly posts do when the queue is full.
+func SetAsyncOverflowPolicy(policy OverflowPolicy) {
+	logger.SetAsyncOverflowPolicy(policy)
+}
+
+// SetAsyncOverflowPolicy sets what async loggly posts do when the queue is
+// full, Drop is the default and -plylogglyblock sets Block.
+func (l *Log) SetAsyncOverflowPolicy(policy OverflowPolicy) {
+	l.mu.Lock()
+	l.logglyBlock = policy == Block
+	l.mu.Unlock()
+}
+
+// DroppedCount returns the number of async loggly posts the global logger dropped.
+func DroppedCount() uint64 {
+	return logger.DroppedCount()
+}
+
+// DroppedCount returns the number of async loggly posts dropped, because
+// the queue was full under Drop or the logger was closed.
+func (l *Log) DroppedCount() uint64 {
+	l.mu.RLock()
+	q := l.logglyQueue
+	l.mu.RUnlock()
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// target is a logger an event is sent to, through its own queue when it
+// is set with SetAsync.
+type target struct {
+	s Sender
+	q *asyncQueue
+}
+
+// send sends ev to the logger of t, or queues it waiting for room so no
+// event is lost. The error of a synchronous send is written to stderr and
+// returned, a queued event only reports to stderr. A synchronous send to a
+// ctxSender gives up once ctx is done.
+func (t target) send(ctx context.Context, ev asyncEvent) error {
+	if t.q != nil {
+		t.q.enqueue(ctx, ev, true)
+		return nil
+	}
+	var err error
+	if cs, ok := ev.s.(ctxSender); ok {
+		err = cs.sendCallerCtx(ctx, ev.severity, ev.env, ev.caller, ev.data)
+	} else {
+		err = sendCaller(ev.s, ev.severity, ev.env, ev.caller, ev.data)
+	}
+	if err != nil {
+		diag(err.Error() + "\n")
+	}
+	return err
+}
+
+// SetAsync sends the events of the named logger of the global logger from a queue, see Log.SetAsync.
+func SetAsync(logType string, bufSize int) {
+	logger.SetAsync(logType, bufSize)
+}
+
+// SetAsync sends the events of the named logger, e.g. "stderr" or "file",
+// from a queue of bufSize events drained by a single worker, keeping their
+// order. A logging call only waits when the queue is full. A bufSize <= 0
+// sends the queued events and returns to sending synchronously. Close
+// sends the queued events too.
+func (l *Log) SetAsync(logType string, bufSize int) {
+	l.mu.Lock()
+	old := l.asyncQueues[logType]
+	if bufSize > 0 {
+		l.asyncQueues[logType] = newAsyncQueue(bufSize, 1)
+	} else {
+		delete(l.asyncQueues, logType)
+	}
+	l.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+}
@@ -0,0 +1,288 @@
+package plywood
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSender records the data of every event sent to it.
+type recordSender struct {
+	m    sync.Mutex
+	data []interface{}
+}
+
+func (s *recordSender) Send(severity, env string, data interface{}) error {
+	s.m.Lock()
+	s.data = append(s.data, data)
+	s.m.Unlock()
+	return nil
+}
+
+func (s *recordSender) events() []interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]interface{}(nil), s.data...)
+}
+
+// blockSender blocks every send until release is closed.
+type blockSender struct {
+	recordSender
+	release chan struct{}
+}
+
+func (s *blockSender) Send(severity, env string, data interface{}) error {
+	<-s.release
+	return s.recordSender.Send(severity, env, data)
+}
+
+func TestAsyncQueueOrder(t *testing.T) {
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.SetLogglyQueue(10, 1)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+	l.logglyBlock = true
+
+	for i := 0; i < 100; i++ {
+		l.Info(i)
+	}
+	l.Close()
+
+	events := s.events()
+	if len(events) != 100 {
+		t.Fatalf("expected 100 events got %d", len(events))
+	}
+	for i, ev := range events {
+		if msg := ev.([]interface{}); msg[0] != i {
+			t.Fatalf("event %d out of order: %v", i, msg)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.SetLogglyQueue(10, 2)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+	l.logglyBlock = true
+
+	for i := 0; i < 50; i++ {
+		l.Info(i)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.events()); n != 50 {
+		t.Fatalf("expected 50 events after flush got %d", n)
+	}
+
+	l.Info("after")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.events()); n != 51 {
+		t.Errorf("expected the queue to keep running got %d events", n)
+	}
+	l.Close()
+}
+
+// panicSender panics on every send.
+type panicSender struct{}
+
+func (panicSender) Send(severity, env string, data interface{}) error {
+	panic("boom")
+}
+
+func TestAsyncQueuePanic(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	s := &recordSender{}
+	q := newAsyncQueue(10, 1)
+	q.enqueue(context.Background(), asyncEvent{s: panicSender{}, caller: "a.go:1:f"}, true)
+	q.enqueue(context.Background(), asyncEvent{s: s, data: "after"}, true)
+	q.close()
+	os.Stderr = stderr
+	w.Close()
+
+	if n := len(s.events()); n != 1 {
+		t.Errorf("expected the worker to survive the panic got %d events", n)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "E a.go:1:f async send panic: boom] \n" {
+		t.Errorf("unexpected stderr %q", b)
+	}
+}
+
+func TestAsyncQueueDrop(t *testing.T) {
+	s := &blockSender{release: make(chan struct{})}
+	q := newAsyncQueue(1, 1)
+	for i := 0; i < 5; i++ {
+		q.enqueue(context.Background(), asyncEvent{s: s, data: i}, false)
+	}
+	close(s.release)
+	q.close()
+
+	sent := len(s.events())
+	dropped := atomic.LoadUint64(&q.dropped)
+	if dropped == 0 {
+		t.Error("expected dropped events")
+	}
+	if sent+int(dropped) != 5 {
+		t.Errorf("expected 5 events got %d sent %d dropped", sent, dropped)
+	}
+
+	q.enqueue(context.Background(), asyncEvent{s: s}, true)
+	if atomic.LoadUint64(&q.dropped) != dropped+1 {
+		t.Error("expected event after close to be dropped")
+	}
+}
+
+func TestOverflowDrop(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	s := &blockSender{release: make(chan struct{})}
+	l := New("test", "production", INFO)
+	l.SetLogglyQueue(1, 1)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+	l.SetAsyncOverflowPolicy(Drop)
+
+	for i := 0; i < 10; i++ {
+		l.Info(i)
+	}
+	dropped := l.DroppedCount()
+	if dropped == 0 {
+		t.Error("expected dropped events")
+	}
+	close(s.release)
+	l.Close()
+	os.Stderr = stderr
+	w.Close()
+
+	if sent := len(s.events()); sent+int(dropped) != 10 {
+		t.Errorf("expected 10 events got %d sent %d dropped", sent, dropped)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first drop is written at once, the rest on close
+	want := "E async queue dropped 1 events] \n"
+	if dropped > 1 {
+		want += "E async queue dropped " + fmt.Sprint(dropped-1) + " events] \n"
+	}
+	if string(b) != want {
+		t.Errorf("expected %q got %q", want, b)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	s := &blockSender{release: make(chan struct{})}
+	l := New("test", "production", INFO)
+	l.SetLogglyQueue(1, 1)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+	l.SetAsyncOverflowPolicy(Block)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			l.Info(i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the caller to block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(s.release)
+	<-done
+	l.Close()
+
+	if n := len(s.events()); n != 10 {
+		t.Errorf("expected 10 events got %d", n)
+	}
+	if n := l.DroppedCount(); n != 0 {
+		t.Errorf("expected nothing dropped got %d", n)
+	}
+}
+
+// slowWriter records writes after a delay of d each.
+type slowWriter struct {
+	syncBuffer
+	d time.Duration
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.d)
+	return w.syncBuffer.Write(p)
+}
+
+func TestSetAsync(t *testing.T) {
+	w := &slowWriter{d: 20 * time.Millisecond}
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: w, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetAsync("stdout", 16)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		l.Infof("event %d", i)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("expected logging to return promptly took %s", d)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := w.String()
+	for i := 0; i < 5; i++ {
+		want := fmt.Sprintf("event %d\n", i)
+		j := strings.Index(out, want)
+		if j < 0 {
+			t.Fatalf("expected Close to flush %q got %q", want, out)
+		}
+		out = out[j:]
+	}
+}
+
+func TestSetAsyncOff(t *testing.T) {
+	var out syncBuffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &out, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetAsync("stdout", 16)
+	l.Info("queued")
+	l.SetAsync("stdout", 0)
+	if !strings.Contains(out.String(), "queued") {
+		t.Errorf("expected the queue sent when turned off got %q", out.String())
+	}
+	l.Info("direct")
+	if !strings.Contains(out.String(), "direct") {
+		t.Errorf("expected a synchronous write got %q", out.String())
+	}
+}
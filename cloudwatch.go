@@ -0,0 +1,253 @@
+package plywood
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PutLogEvents limits, each event counts its message plus 26 bytes.
+const (
+	cloudWatchMaxEvents     = 10000
+	cloudWatchMaxBytes      = 1048576
+	cloudWatchEventOverhead = 26
+)
+
+const (
+	defaultCloudWatchFlushInterval = 5 * time.Second
+	defaultCloudWatchTimeout       = 30 * time.Second
+)
+
+// CloudWatchEvent is a log event of a PutLogEvents call.
+type CloudWatchEvent struct {
+	Timestamp int64 // milliseconds since the epoch
+	Message   string
+}
+
+// CloudWatchPutInput is the request of a PutLogEvents call, its events
+// are in timestamp order.
+type CloudWatchPutInput struct {
+	Group         string
+	Stream        string
+	SequenceToken string // empty for the first call to a stream
+	Events        []CloudWatchEvent
+}
+
+// CloudWatchClient puts log events to CloudWatch Logs and returns the
+// next sequence token. plywood does not import the AWS SDK, wrap its
+// client in a CloudWatchClient and return a *CloudWatchSequenceError for
+// an InvalidSequenceTokenException.
+type CloudWatchClient interface {
+	PutLogEvents(ctx context.Context, in *CloudWatchPutInput) (nextToken string, err error)
+}
+
+// CloudWatchSequenceError is returned by a CloudWatchClient when the
+// sequence token of a call is not the one expected by the stream.
+type CloudWatchSequenceError struct {
+	Expected string
+}
+
+func (e *CloudWatchSequenceError) Error() string {
+	return "cloudwatch: invalid sequence token, expected " + e.Expected
+}
+
+// CloudWatchConfig configures the CloudWatch logger, only Client is required.
+type CloudWatchConfig struct {
+	Client        CloudWatchClient
+	BatchSize     int           // events buffered before a put, at most 10000
+	FlushInterval time.Duration // puts the buffered events, 5s by default
+	Timeout       time.Duration // of each put, 30s by default
+}
+
+// CloudWatch implements sender and buffers log events, put to a CloudWatch
+// Logs stream when the batch is full, every flush interval and on Close.
+type CloudWatch struct {
+	group     string
+	stream    string
+	client    CloudWatchClient
+	batchSize int
+	timeout   time.Duration
+	log       *Log // owning log, source of the clock and post fields
+
+	m      *sync.Mutex // guards events, size and stop
+	events []CloudWatchEvent
+	size   int // bytes of events as PutLogEvents counts them
+	stop   chan struct{}
+
+	pm    *sync.Mutex // serializes puts, guards token
+	token string
+}
+
+// newCloudWatch creates a CloudWatch sender for group and stream.
+func newCloudWatch(l *Log, group, stream string, cfg CloudWatchConfig) *CloudWatch {
+	s := &CloudWatch{
+		group:     group,
+		stream:    stream,
+		client:    cfg.Client,
+		batchSize: cfg.BatchSize,
+		timeout:   cfg.Timeout,
+		log:       l,
+		m:         &sync.Mutex{},
+		pm:        &sync.Mutex{},
+	}
+	if s.batchSize <= 0 || s.batchSize > cloudWatchMaxEvents {
+		s.batchSize = cloudWatchMaxEvents
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultCloudWatchTimeout
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = defaultCloudWatchFlushInterval
+	}
+	s.stop = make(chan struct{})
+	go s.tick(interval, s.stop)
+	return s
+}
+
+// tick flushes s every interval until stop is closed.
+func (s *CloudWatch) tick(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				diag(err.Error() + "\n")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Send a log event to CloudWatch.
+func (s *CloudWatch) Send(severity, env string, data interface{}) error {
+	s.log.mu.RLock()
+	caller := s.log.callerName(1)
+	s.log.mu.RUnlock()
+	return s.sendCaller(severity, env, caller, data)
+}
+
+// sendCaller buffers a log event logged by caller as its loggly json
+// post, the batch is put once it is full.
+func (s *CloudWatch) sendCaller(severity, env, caller string, data interface{}) error {
+	buf, err := encodePost(s.log, severity, env, caller, data)
+	if err != nil {
+		return err
+	}
+	ev := CloudWatchEvent{Message: buf.String()}
+	putBuffer(buf)
+	s.log.mu.RLock()
+	ev.Timestamp = s.log.now().UnixNano() / int64(time.Millisecond)
+	s.log.mu.RUnlock()
+
+	s.m.Lock()
+	s.events = append(s.events, ev)
+	s.size += len(ev.Message) + cloudWatchEventOverhead
+	full := len(s.events) >= s.batchSize || s.size >= cloudWatchMaxBytes
+	s.m.Unlock()
+	if full {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush puts the buffered events.
+func (s *CloudWatch) Flush() error {
+	s.m.Lock()
+	events := s.events
+	s.events, s.size = nil, 0
+	s.m.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.pm.Lock()
+	defer s.pm.Unlock()
+	var first error
+	for _, batch := range cloudWatchBatches(events, s.batchSize) {
+		if err := s.put(batch); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// put puts a batch with the current sequence token, once more with the
+// expected one when the stream rejects it. The caller must hold s.pm.
+func (s *CloudWatch) put(batch []CloudWatchEvent) error {
+	in := &CloudWatchPutInput{
+		Group:         s.group,
+		Stream:        s.stream,
+		SequenceToken: s.token,
+		Events:        batch,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	token, err := s.client.PutLogEvents(ctx, in)
+	var seqErr *CloudWatchSequenceError
+	if errors.As(err, &seqErr) {
+		in.SequenceToken = seqErr.Expected
+		token, err = s.client.PutLogEvents(ctx, in)
+	}
+	if err != nil {
+		return fmt.Errorf("cloudwatch: %d events not put: %v", len(batch), err)
+	}
+	s.token = token
+	return nil
+}
+
+// cloudWatchBatches sorts events by timestamp, as PutLogEvents requires,
+// and splits them into batches of at most max events and 1MB.
+func cloudWatchBatches(events []CloudWatchEvent, max int) [][]CloudWatchEvent {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	var batches [][]CloudWatchEvent
+	start, size := 0, 0
+	for i, ev := range events {
+		n := len(ev.Message) + cloudWatchEventOverhead
+		if i > start && (i-start >= max || size+n > cloudWatchMaxBytes) {
+			batches = append(batches, events[start:i])
+			start, size = i, 0
+		}
+		size += n
+	}
+	return append(batches, events[start:])
+}
+
+// Close stops the flush ticker and puts the buffered events.
+func (s *CloudWatch) Close() error {
+	s.m.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.m.Unlock()
+	return s.Flush()
+}
+
+// SetCloudWatch sets the cloudwatch logger of the global logger, see Log.SetCloudWatch.
+func SetCloudWatch(group, stream string, cfg CloudWatchConfig) error {
+	return logger.SetCloudWatch(group, stream, cfg)
+}
+
+// SetCloudWatch adds a logger named cloudwatch putting the events, as
+// loggly json posts, to the log stream of group through cfg.Client. Like
+// the loggers of SetMultiLogger it is on from the start and its level is
+// set with SetLoggerLevel. Setting it again puts the events buffered by
+// the old one.
+func (l *Log) SetCloudWatch(group, stream string, cfg CloudWatchConfig) error {
+	if cfg.Client == nil {
+		return fmt.Errorf("cloudwatch client not set")
+	}
+	old := l.setPostLogger("cloudwatch", newCloudWatch(l, group, stream, cfg))
+	if old, ok := old.(*CloudWatch); ok {
+		return old.Close()
+	}
+	return nil
+}
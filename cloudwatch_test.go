@@ -0,0 +1,153 @@
+package plywood
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cloudWatchRecorder is a fake CloudWatch Logs stream recording every put.
+type cloudWatchRecorder struct {
+	m      sync.Mutex
+	puts   []CloudWatchPutInput
+	token  string // expected sequence token
+	reject bool   // reject the next put with a sequence error
+}
+
+func (c *cloudWatchRecorder) PutLogEvents(ctx context.Context, in *CloudWatchPutInput) (string, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.reject || in.SequenceToken != c.token {
+		c.reject = false
+		return "", &CloudWatchSequenceError{Expected: c.token}
+	}
+	c.puts = append(c.puts, *in)
+	c.token = fmt.Sprintf("token-%d", len(c.puts))
+	return c.token, nil
+}
+
+func (c *cloudWatchRecorder) recorded() []CloudWatchPutInput {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]CloudWatchPutInput(nil), c.puts...)
+}
+
+func TestCloudWatch(t *testing.T) {
+	c := &cloudWatchRecorder{}
+	l := New("test", "production", INFO)
+	now := time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC)
+	l.SetClock(func() time.Time { return now })
+	if err := l.SetCloudWatch("group", "stream", CloudWatchConfig{Client: c, BatchSize: 3, FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 7; i++ {
+		l.Info(i)
+	}
+	if n := len(c.recorded()); n != 2 {
+		t.Fatalf("expected 2 full batches put got %d", n)
+	}
+	l.Close()
+
+	puts := c.recorded()
+	if len(puts) != 3 {
+		t.Fatalf("expected 3 puts got %d", len(puts))
+	}
+	for i, want := range []int{3, 3, 1} {
+		if len(puts[i].Events) != want {
+			t.Errorf("put %d: expected %d events got %d", i, want, len(puts[i].Events))
+		}
+		if puts[i].Group != "group" || puts[i].Stream != "stream" {
+			t.Errorf("put %d: unexpected stream %s/%s", i, puts[i].Group, puts[i].Stream)
+		}
+	}
+	if puts[0].SequenceToken != "" || puts[1].SequenceToken != "token-1" || puts[2].SequenceToken != "token-2" {
+		t.Errorf("expected the returned tokens passed on got %q %q %q",
+			puts[0].SequenceToken, puts[1].SequenceToken, puts[2].SequenceToken)
+	}
+
+	ev := puts[0].Events[0]
+	if ev.Timestamp != now.UnixNano()/int64(time.Millisecond) {
+		t.Errorf("unexpected timestamp %d", ev.Timestamp)
+	}
+	var post map[string]interface{}
+	if err := json.Unmarshal([]byte(ev.Message), &post); err != nil {
+		t.Fatalf("%s: %s", err, ev.Message)
+	}
+	if post["app"] != "test" || post["msg"].(map[string]interface{})["int"] != 0.0 {
+		t.Errorf("unexpected post %v", post)
+	}
+}
+
+func TestCloudWatchSequenceToken(t *testing.T) {
+	c := &cloudWatchRecorder{token: "from-another-writer"}
+	l := New("test", "production", INFO)
+	if err := l.SetCloudWatch("group", "stream", CloudWatchConfig{Client: c, FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("first")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	c.m.Lock()
+	c.reject = true
+	c.m.Unlock()
+	l.Info("second")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	puts := c.recorded()
+	if len(puts) != 2 {
+		t.Fatalf("expected 2 puts got %d", len(puts))
+	}
+	if puts[0].SequenceToken != "from-another-writer" || puts[1].SequenceToken != "token-1" {
+		t.Errorf("expected the expected tokens retried got %q %q", puts[0].SequenceToken, puts[1].SequenceToken)
+	}
+}
+
+func TestCloudWatchOrder(t *testing.T) {
+	c := &cloudWatchRecorder{}
+	l := New("test", "production", INFO)
+	start := time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC)
+	now := start
+	l.SetClock(func() time.Time { return now })
+	if err := l.SetCloudWatch("group", "stream", CloudWatchConfig{Client: c, FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range []int{30, 10, 20} {
+		now = start.Add(time.Duration(ms) * time.Millisecond)
+		l.Info(ms)
+	}
+	l.Close()
+
+	events := c.recorded()[0].Events
+	for i := 1; i < len(events); i++ {
+		if events[i-1].Timestamp > events[i].Timestamp {
+			t.Fatalf("events out of order %v", events)
+		}
+	}
+	if !strings.Contains(events[0].Message, `{"int":10}`) {
+		t.Errorf("expected the earliest event first got %s", events[0].Message)
+	}
+}
+
+func TestCloudWatchBatchBytes(t *testing.T) {
+	msg := strings.Repeat("x", 400*1024)
+	events := []CloudWatchEvent{{1, msg}, {2, msg}, {3, msg}}
+	batches := cloudWatchBatches(events, cloudWatchMaxEvents)
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("expected batches of 2 and 1 events got %d", len(batches))
+	}
+}
+
+func TestCloudWatchNoClient(t *testing.T) {
+	l := New("test", "production", INFO)
+	if err := l.SetCloudWatch("group", "stream", CloudWatchConfig{}); err == nil {
+		t.Error("expected an error without a client")
+	}
+}
@@ -0,0 +1,72 @@
+package plywood
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI escape codes used to color the console header by level.
+const (
+	colorReset  = "\x1b[0m"
+	colorGray   = "\x1b[90m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+)
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorDefault reports whether console output is colored by default,
+// only on a terminal and unless NO_COLOR is set or TERM is dumb, see
+// https://no-color.org.
+func colorDefault(terminal bool) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return terminal
+}
+
+// colorize colors the header of a text formatted line by severity,
+// lines not starting with the severity character are left untouched.
+func colorize(severity, line string) string {
+	color := levelOf(severityLevel(severity)).color
+	if color == "" || !strings.HasPrefix(line, severity) {
+		return line
+	}
+	i := strings.Index(line, "] ")
+	if i < 0 {
+		return line
+	}
+	return color + line[:i+1] + colorReset + line[i+1:]
+}
+
+// SetColor forces the color of the global logger's console output on or off.
+func SetColor(on bool) {
+	logger.SetColor(on)
+}
+
+// SetColor forces the color of console output on or off. By default the
+// header is colored by level only when the console is a terminal, and
+// NO_COLOR is not set and TERM is not dumb.
+func (l *Log) SetColor(on bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, s := range l.Loggers {
+		if c, ok := s.(*Console); ok {
+			c.m.Lock()
+			c.color = on
+			c.m.Unlock()
+		}
+	}
+}
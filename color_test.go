@@ -0,0 +1,98 @@
+package plywood
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", DEBUG)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	l.SetColor(true)
+	tests := []struct {
+		log   func(...interface{}) error
+		color string
+	}{
+		{l.Debug, colorGray},
+		{l.Warning, colorYellow},
+		{l.Error, colorRed},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		tt.log("colored")
+		if !strings.HasPrefix(buf.String(), tt.color) || !strings.Contains(buf.String(), colorReset+" colored") {
+			t.Errorf("expected %q colored header got %q", tt.color, buf.String())
+		}
+	}
+	buf.Reset()
+	l.Info("plain")
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("info colored %q", buf.String())
+	}
+
+	l.SetColor(false)
+	buf.Reset()
+	l.Error("plain")
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("color off still colored %q", buf.String())
+	}
+}
+
+func TestColorJSONUntouched(t *testing.T) {
+	line := `{"level":"E","msg":"x"}` + "\n"
+	if c := colorize("E", line); c != line {
+		t.Errorf("json line colored %q", c)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if isTerminal(&bytes.Buffer{}) {
+		t.Error("buffer is not a terminal")
+	}
+	f, err := ioutil.TempFile("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("file is not a terminal")
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	for _, env := range [][2]string{{"NO_COLOR", "1"}, {"TERM", "dumb"}} {
+		old, set := os.LookupEnv(env[0])
+		os.Setenv(env[0], env[1])
+		if colorDefault(true) {
+			t.Errorf("%s=%s: expected no color on a terminal", env[0], env[1])
+		}
+		var buf bytes.Buffer
+		l := New("test", "testing", INFO)
+		l.SetLogger("stderr")
+		c := l.Loggers["stderr"].(*Console)
+		c.w = &buf
+		l.toStderr = true
+		l.Error("plain")
+		if strings.Contains(buf.String(), "\x1b[") {
+			t.Errorf("%s=%s: colored output %q", env[0], env[1], buf.String())
+		}
+		if set {
+			os.Setenv(env[0], old)
+		} else {
+			os.Unsetenv(env[0])
+		}
+	}
+	if os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" {
+		if !colorDefault(true) || colorDefault(false) {
+			t.Error("expected color on a terminal only")
+		}
+	}
+}
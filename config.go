@@ -0,0 +1,110 @@
+package plywood
+
+import (
+	"sort"
+	"time"
+)
+
+// Config is a snapshot of the configuration of a Log, the loggly token is
+// left out.
+type Config struct {
+	App                 string
+	Env                 string
+	Host                string
+	Level               uint
+	LoggerLevels        map[string]uint // per logger minimum levels
+	Loggers             []string        // names of the registered loggers, sorted
+	Format              string
+	LineSeparator       string
+	Prefix              string
+	CallerFormat        string
+	ToStderr            bool
+	ToStdout            bool
+	ToFile              bool
+	ToLoggly            bool
+	ToLogglyAsync       bool
+	ToWebhook           bool
+	ToWebhookAsync      bool
+	ToSyslog            bool
+	LogglyHost          string
+	LogglyEnvironments  []string // sorted
+	LogglyBatchSize     int
+	LogglyFlushInterval time.Duration
+	LogglyRetries       int
+	LogglyRetryDelay    time.Duration
+	LogglyTimeout       time.Duration
+	LogglySendTimeout   time.Duration
+	TimeTrackThreshold  float64
+}
+
+func Environment() string         { return logger.Environment() }
+func TimeTrackThreshold() float64 { return logger.TimeTrackThreshold() }
+
+// Environment returns the logging environment. It reads Env under the
+// config lock, unlike the field itself.
+func (l *Log) Environment() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.Env
+}
+
+// TimeTrackThreshold returns the TimeTrack threshold in milliseconds.
+func (l *Log) TimeTrackThreshold() float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.timeTrackThreshold
+}
+
+// Config returns a snapshot of the configuration of l. There is no package
+// level function as the name is taken by the Config type.
+func (l *Log) Config() Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c := Config{
+		App:                 l.App,
+		Env:                 l.Env,
+		Host:                l.Host,
+		Level:               l.level,
+		LoggerLevels:        make(map[string]uint, len(l.loggerLevels)),
+		Format:              l.format,
+		LineSeparator:       l.lineSep,
+		Prefix:              l.prefix,
+		CallerFormat:        l.callerFormat,
+		ToStderr:            l.toStderr,
+		ToStdout:            l.toStdout,
+		ToFile:              l.toFile,
+		ToLoggly:            l.toLoggly,
+		ToLogglyAsync:       l.toLogglya,
+		ToWebhook:           l.toWebhook,
+		ToWebhookAsync:      l.toWebhooka,
+		ToSyslog:            l.toSyslog,
+		LogglyHost:          l.logglyHost,
+		LogglyBatchSize:     l.logglyBatchSize,
+		LogglyFlushInterval: l.logglyFlushInterval,
+		LogglyRetries:       l.logglyRetries,
+		LogglyRetryDelay:    l.logglyRetryDelay,
+		LogglyTimeout:       l.logglyTimeout,
+		LogglySendTimeout:   l.logglySendTimeout,
+		TimeTrackThreshold:  l.timeTrackThreshold,
+	}
+	if c.Format == "" {
+		c.Format = FormatText
+	}
+	if c.CallerFormat == "" {
+		c.CallerFormat = CallerFile
+	}
+	for name, level := range l.loggerLevels {
+		c.LoggerLevels[name] = level
+	}
+	for name := range l.Loggers {
+		c.Loggers = append(c.Loggers, name)
+	}
+	sort.Strings(c.Loggers)
+	for env, ok := range l.logglyEnvs {
+		if ok {
+			c.LogglyEnvironments = append(c.LogglyEnvironments, env)
+		}
+	}
+	sort.Strings(c.LogglyEnvironments)
+	return c
+}
@@ -0,0 +1,44 @@
+package plywood
+
+import (
+	"testing"
+)
+
+func TestGetters(t *testing.T) {
+	l := New("test", "staging", INFO)
+	l.SetLevel(ERROR)
+	l.SetEnv("production")
+	l.SetTimeTrackThreshold(12.5)
+	if l.Level() != ERROR || l.Environment() != "production" || l.TimeTrackThreshold() != 12.5 {
+		t.Errorf("unexpected getters %d %s %v", l.Level(), l.Environment(), l.TimeTrackThreshold())
+	}
+}
+
+func TestConfig(t *testing.T) {
+	l := New("test", "staging", INFO)
+	l.SetLoggerLevel("stdout", DEBUG)
+	l.SetLogglyEnvironments("qa", "production")
+	l.SetLogglyToken("secret")
+	l.SetLogglyRetries(3)
+	l.EnableLogglyAsync(true)
+	l.SetFormat(FormatJSON)
+
+	c := l.Config()
+	if c.App != "test" || c.Env != "staging" || c.Level != INFO || c.Format != FormatJSON {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if c.LoggerLevels["stdout"] != DEBUG || c.LogglyRetries != 3 || !c.ToLogglyAsync || c.ToLoggly {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if len(c.LogglyEnvironments) != 2 || c.LogglyEnvironments[0] != "production" || c.LogglyEnvironments[1] != "qa" {
+		t.Errorf("unexpected loggly environments %v", c.LogglyEnvironments)
+	}
+	if len(c.Loggers) != 1 || c.Loggers[0] != "loggly" {
+		t.Errorf("unexpected loggers %v", c.Loggers)
+	}
+
+	c.LoggerLevels["stdout"] = FATAL
+	if l.loggerLevels["stdout"] != DEBUG {
+		t.Error("expected the snapshot to be a copy")
+	}
+}
@@ -0,0 +1,235 @@
+package plywood
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// Flush writes the buffered console output.
+func (c *Console) Flush() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.buf == nil {
+		return nil
+	}
+	return c.buf.Flush()
+}
+
+// Close stops the flush ticker and writes the buffered output, later
+// events are written unbuffered. The underlying writer is left open.
+func (c *Console) Close() error {
+	return c.setBuffer(0, 0)
+}
+
+// setBuffer buffers up to size bytes, flushed when full and every interval.
+// A size <= 0 flushes and turns buffering off.
+func (c *Console) setBuffer(size int, interval time.Duration) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	var err error
+	if c.buf != nil {
+		err = c.buf.Flush()
+		c.buf = nil
+	}
+	if size <= 0 {
+		return err
+	}
+	c.buf = bufio.NewWriterSize(c.w, size)
+	if interval <= 0 {
+		return err
+	}
+	stop := make(chan struct{})
+	c.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Flush(); err != nil {
+					diag(err.Error() + "\n")
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return err
+}
+
+// setWriter makes c write to w, the buffered output is written to the
+// old writer first. Color is turned on when w is a terminal, as for a new
+// console.
+func (c *Console) setWriter(w io.Writer) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	var err error
+	if c.buf != nil {
+		err = c.buf.Flush()
+		c.buf = bufio.NewWriterSize(w, c.buf.Size())
+	}
+	c.w = w
+	c.color = colorDefault(isTerminal(w))
+	return err
+}
+
+// SetStderrWriter redirects the global logger's stderr console to w, see Log.SetStderrWriter.
+func SetStderrWriter(w io.Writer) error {
+	return logger.SetStderrWriter(w)
+}
+
+// SetStderrWriter redirects the lines of the stderr logger to w without
+// replacing the logger, e.g. to capture them in tests. Pass os.Stderr to
+// restore it. Diagnostics of plywood itself still go to os.Stderr.
+func (l *Log) SetStderrWriter(w io.Writer) error {
+	return l.setConsoleWriter("stderr", w)
+}
+
+// SetStdoutWriter redirects the global logger's stdout console to w, see Log.SetStdoutWriter.
+func SetStdoutWriter(w io.Writer) error {
+	return logger.SetStdoutWriter(w)
+}
+
+// SetStdoutWriter redirects the lines of the stdout logger to w without
+// replacing the logger. Pass os.Stdout to restore it.
+func (l *Log) SetStdoutWriter(w io.Writer) error {
+	return l.setConsoleWriter("stdout", w)
+}
+
+// setConsoleWriter points the named console logger at w, creating the
+// logger when it is missing or was replaced by another sender.
+func (l *Log) setConsoleWriter(name string, w io.Writer) error {
+	l.mu.RLock()
+	c, ok := l.Loggers[name].(*Console)
+	l.mu.RUnlock()
+	if !ok {
+		if err := l.SetLogger(name); err != nil {
+			return err
+		}
+		l.mu.RLock()
+		c = l.Loggers[name].(*Console)
+		l.mu.RUnlock()
+	}
+	return c.setWriter(w)
+}
+
+// SetConsoleBuffer buffers up to size bytes of the global logger's console output.
+func SetConsoleBuffer(size int, interval time.Duration) {
+	logger.SetConsoleBuffer(size, interval)
+}
+
+// SetConsoleBuffer buffers up to size bytes of console output, written
+// when the buffer fills, every interval and on Close, which Fatal calls
+// before exiting. A size <= 0 turns buffering back off.
+func (l *Log) SetConsoleBuffer(size int, interval time.Duration) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, s := range l.Loggers {
+		if c, ok := s.(*Console); ok {
+			if err := c.setBuffer(size, interval); err != nil {
+				diag(err.Error() + "\n")
+			}
+		}
+	}
+}
+
+// AddWriter adds a logger named name writing the console lines to w on the global logger, see Log.AddWriter.
+func AddWriter(name string, w io.Writer) {
+	logger.AddWriter(name, w)
+}
+
+// AddWriter adds a logger named name writing the console lines to w, which
+// is written by one event at a time. The logger is on from the start, its
+// level is set with SetLoggerLevel(name, level). Adding a name again
+// replaces its writer, the stderr, stdout, file and discard names replace
+// that logger and keep its Enable setting. w is not closed by Close.
+func (l *Log) AddWriter(name string, w io.Writer) {
+	if postLogger(name) {
+		diag("E " + name + " is not a writer logger] \n")
+		return
+	}
+	c := &Console{w: w, m: &sync.Mutex{}}
+	l.mu.Lock()
+	old := l.Loggers[name]
+	l.Loggers[name] = c
+	if !lineLogger(name) && !contains(l.writers, name) {
+		l.writers = append(l.writers, name)
+	}
+	l.multis = remove(l.multis, name)
+	l.mu.Unlock()
+	if closer, ok := old.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// lineLogger reports whether name is one of the built in line loggers.
+func lineLogger(name string) bool {
+	switch name {
+	case "stderr", "stdout", "file", "discard":
+		return true
+	}
+	return false
+}
+
+// postLogger reports whether name is one of the built in structured loggers.
+func postLogger(name string) bool {
+	switch name {
+	case "loggly", "webhook", "syslog", "memory":
+		return true
+	}
+	return false
+}
+
+// remove returns names without name.
+func remove(names []string, name string) []string {
+	for i, n := range names {
+		if n == name {
+			return append(names[:i:i], names[i+1:]...)
+		}
+	}
+	return names
+}
+
+// contains reports whether names holds name.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSplitStreams splits the global logger's console output by level, see Log.SetSplitStreams.
+func SetSplitStreams(on bool) {
+	logger.SetSplitStreams(on)
+}
+
+// SetSplitStreams writes WARNING, ERROR and FATAL events to stderr and
+// DEBUG and INFO ones to stdout, so pipelines can tell them apart. While
+// on, EnableStderr and EnableStdout have no effect, the per logger levels
+// still apply.
+func (l *Log) SetSplitStreams(on bool) {
+	if on {
+		for _, name := range []string{"stderr", "stdout"} {
+			l.mu.RLock()
+			_, ok := l.Loggers[name]
+			l.mu.RUnlock()
+			if !ok {
+				l.SetLogger(name)
+			}
+		}
+	}
+	l.mu.Lock()
+	l.splitStreams = on
+	if on {
+		l.toDiscard = false
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,295 @@
+package plywood
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the flush ticker and the test to share.
+type syncBuffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.b.String()
+}
+
+func TestConsoleBufferClose(t *testing.T) {
+	var out syncBuffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &out, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetConsoleBuffer(4096, 0)
+
+	l.Info("pending")
+	if out.String() != "" {
+		t.Fatalf("expected the event buffered got %q", out.String())
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "pending") {
+		t.Errorf("expected Close to flush got %q", out.String())
+	}
+}
+
+func TestConsoleBufferFlush(t *testing.T) {
+	var out syncBuffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &out, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetConsoleBuffer(64, 10*time.Millisecond)
+	defer l.Close()
+
+	l.Info(strings.Repeat("x", 100))
+	if !strings.Contains(out.String(), "xxx") {
+		t.Errorf("expected a full buffer to flush got %q", out.String())
+	}
+	l.Info("tick")
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "tick") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(out.String(), "tick") {
+		t.Errorf("expected the interval to flush got %q", out.String())
+	}
+}
+
+func TestSetLoggerClosesConsole(t *testing.T) {
+	var out syncBuffer
+	l := New("test", "production", INFO)
+	old := &Console{w: &out, m: &sync.Mutex{}}
+	l.Loggers["stdout"] = old
+	l.toStdout = true
+	l.SetConsoleBuffer(4096, time.Hour)
+	l.Info("pending")
+
+	l.SetLogger("stdout")
+	if !strings.Contains(out.String(), "pending") {
+		t.Errorf("expected the replaced console flushed got %q", out.String())
+	}
+	old.m.Lock()
+	defer old.m.Unlock()
+	if old.stop != nil || old.buf != nil {
+		t.Error("expected the flush ticker of the replaced console stopped")
+	}
+}
+
+func TestFatalFlushesConsole(t *testing.T) {
+	osExit = func(int) {}
+	defer func() { osExit = os.Exit }()
+
+	var out syncBuffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &out, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetConsoleBuffer(4096, time.Hour)
+	l.Fatal("bye")
+	if !strings.Contains(out.String(), "bye") {
+		t.Errorf("expected Fatal to flush got %q", out.String())
+	}
+}
+
+func benchmarkConsole(b *testing.B, size int) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: f, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetConsoleBuffer(size, time.Second)
+	defer l.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("event")
+	}
+}
+
+func BenchmarkConsoleUnbuffered(b *testing.B) { benchmarkConsole(b, 0) }
+func BenchmarkConsoleBuffered(b *testing.B)   { benchmarkConsole(b, 64*1024) }
+
+func TestAddWriter(t *testing.T) {
+	l := New("test", "testing", INFO)
+	l.toStderr = false
+	var buf, audit bytes.Buffer
+	l.AddWriter("buf", &buf)
+	l.AddWriter("audit", &audit)
+	l.SetLoggerLevel("audit", ERROR)
+
+	l.Info("hello")
+	l.Errorf("failed %d", 2)
+	l.Debug("hidden")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "] hello") || !strings.HasSuffix(lines[1], "] failed 2") {
+		t.Errorf("unexpected buf output %q", buf.String())
+	}
+	if s := audit.String(); !strings.HasPrefix(s, "E") || !strings.HasSuffix(s, "] failed 2\n") || strings.Count(s, "\n") != 1 {
+		t.Errorf("unexpected audit output %q", s)
+	}
+
+	var other bytes.Buffer
+	l.AddWriter("buf", &other)
+	l.Info("again")
+	if strings.Contains(buf.String(), "again") || !strings.HasSuffix(other.String(), "] again\n") || strings.Count(other.String(), "\n") != 1 {
+		t.Errorf("expected the writer replaced, buf %q other %q", buf.String(), other.String())
+	}
+}
+
+func TestSplitStreams(t *testing.T) {
+	var stderr, stdout bytes.Buffer
+	l := New("test", "testing", DEBUG)
+	l.Loggers["stderr"] = &Console{w: &stderr, m: &sync.Mutex{}}
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStderr, l.toStdout = true, false
+	l.SetSplitStreams(true)
+
+	l.Debug("d")
+	l.Info("i")
+	l.Warning("w")
+	l.Error("e")
+
+	for name, tt := range map[string]struct {
+		buf  *bytes.Buffer
+		want []string
+	}{
+		"stdout": {&stdout, []string{"D", "I"}},
+		"stderr": {&stderr, []string{"W", "E"}},
+	} {
+		lines := strings.Split(strings.TrimSuffix(tt.buf.String(), "\n"), "\n")
+		if len(lines) != len(tt.want) {
+			t.Fatalf("%s: expected %d lines got %q", name, len(tt.want), tt.buf.String())
+		}
+		for i, severity := range tt.want {
+			if !strings.HasPrefix(lines[i], severity) {
+				t.Errorf("%s: expected a %s line got %q", name, severity, lines[i])
+			}
+		}
+	}
+
+	stderr.Reset()
+	stdout.Reset()
+	l.SetSplitStreams(false)
+	l.Info("unsplit")
+	if stdout.Len() != 0 || !strings.HasSuffix(stderr.String(), "] unsplit\n") {
+		t.Errorf("expected the enable settings back, stdout %q stderr %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestStderrNotTorn(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	out := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- b
+	}()
+
+	// longer than PIPE_BUF so a single write is not atomic on its own
+	msg := strings.Repeat("x", 5000)
+	l := New("test", "testing", INFO)
+	l.SetLogger("stderr")
+	l.toStderr = true
+	l.SetLogglyQueue(100, 4)
+	l.Loggers["loggly"] = failSender{errors.New(strings.Repeat("y", 5000))}
+	l.toLogglya = true
+	l.logglyBlock = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Info(msg)
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+	os.Stderr = stderr
+	w.Close()
+
+	lines := strings.Split(strings.TrimSuffix(string(<-out), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("expected 800 lines got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line != strings.Repeat("y", 5000) && !strings.HasSuffix(line, "] "+msg) {
+			t.Fatalf("torn line %.80q", line)
+		}
+	}
+}
+
+func TestSetStderrWriter(t *testing.T) {
+	var stderr, stdout syncBuffer
+	c := logger.Config()
+	EnableStderr(true)
+	EnableStdout(true)
+	defer EnableStderr(c.ToStderr)
+	defer EnableStdout(c.ToStdout)
+	if err := SetStderrWriter(&stderr); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetStdoutWriter(&stdout); err != nil {
+		t.Fatal(err)
+	}
+
+	Info("captured")
+	if !strings.HasSuffix(stderr.String(), "] captured\n") {
+		t.Errorf("expected the stderr line captured got %q", stderr.String())
+	}
+	if !strings.HasSuffix(stdout.String(), "] captured\n") {
+		t.Errorf("expected the stdout line captured got %q", stdout.String())
+	}
+
+	SetStderrWriter(os.Stderr)
+	SetStdoutWriter(os.Stdout)
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	if c := logger.Loggers["stderr"].(*Console); c.w != os.Stderr {
+		t.Error("expected stderr restored")
+	}
+	if c := logger.Loggers["stdout"].(*Console); c.w != os.Stdout {
+		t.Error("expected stdout restored")
+	}
+}
+
+type consoleStringer struct{ id int }
+
+func (s consoleStringer) String() string { return fmt.Sprintf("stringer %d\n", s.id) }
+
+func TestConsoleSendTypes(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Console{w: &buf, m: &sync.Mutex{}}
+	c.Send("I", "testing", []byte("bytes\n"))
+	c.Send("I", "testing", consoleStringer{7})
+	c.Send("I", "testing", 42)
+	c.Send("I", "testing", nil)
+	if want := "bytes\nstringer 7\n42"; buf.String() != want {
+		t.Errorf("expected %q got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package plywood
+
+import (
+	"context"
+)
+
+// contextKey is the key under which logging fields are stored in a context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying fields, combined with
+// any fields already stored in ctx.
+func NewContext(ctx context.Context, fields map[string]interface{}) context.Context {
+	parent := FromContext(ctx)
+	merged := make(map[string]interface{}, len(parent)+len(fields))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// FromContext returns the logging fields stored in ctx, nil if there are none.
+func FromContext(ctx context.Context) map[string]interface{} {
+	fields, _ := ctx.Value(contextKey{}).(map[string]interface{})
+	return fields
+}
+
+func DebugCtx(ctx context.Context, msg ...interface{}) error {
+	return logger.logCtx(ctx, DEBUG, msg...)
+}
+func InfoCtx(ctx context.Context, msg ...interface{}) error { return logger.logCtx(ctx, INFO, msg...) }
+func WarningCtx(ctx context.Context, msg ...interface{}) error {
+	return logger.logCtx(ctx, WARNING, msg...)
+}
+func ErrorCtx(ctx context.Context, msg ...interface{}) error {
+	return logger.logCtx(ctx, ERROR, msg...)
+}
+
+func (l *Log) DebugCtx(ctx context.Context, msg ...interface{}) error {
+	return l.logCtx(ctx, DEBUG, msg...)
+}
+func (l *Log) InfoCtx(ctx context.Context, msg ...interface{}) error {
+	return l.logCtx(ctx, INFO, msg...)
+}
+func (l *Log) WarningCtx(ctx context.Context, msg ...interface{}) error {
+	return l.logCtx(ctx, WARNING, msg...)
+}
+func (l *Log) ErrorCtx(ctx context.Context, msg ...interface{}) error {
+	return l.logCtx(ctx, ERROR, msg...)
+}
+
+// logCtx is called by the context aware logging functions, the fields
+// stored in ctx and the ids of its span are merged into the event.
+func (l *Log) logCtx(ctx context.Context, level uint, msg ...interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	if fields := FromContext(ctx); len(fields) > 0 {
+		l = l.WithFields(fields)
+	}
+	l.mu.RLock()
+	extract := l.traceExtractor
+	l.mu.RUnlock()
+	if extract != nil {
+		if traceID, spanID := extract(ctx); traceID != "" || spanID != "" {
+			l = l.WithTrace(traceID, spanID)
+		}
+	}
+	return l.send(ctx, level, "", msg, false, "")
+}
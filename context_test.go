@@ -0,0 +1,69 @@
+package plywood
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestContextFields(t *testing.T) {
+	ctx := NewContext(context.Background(), map[string]interface{}{"request_id": "r-1"})
+	ctx = NewContext(ctx, map[string]interface{}{"user_id": 3})
+	fields := FromContext(ctx)
+	if fields["request_id"] != "r-1" || fields["user_id"] != 3 {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if FromContext(context.Background()) != nil {
+		t.Error("expected no fields")
+	}
+}
+
+func TestInfoCtx(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	var buf bytes.Buffer
+	l := New("test", "production", DEBUG)
+	useRecorder(l, r)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	ctx := NewContext(context.Background(), map[string]interface{}{"request_id": "r-42"})
+	l.InfoCtx(ctx, "handled")
+	l.DebugCtx(context.Background(), "no fields")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], " request_id=r-42] handled") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+	posts := r.posts(t)
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts got %d", len(posts))
+	}
+	if msg := posts[0].Msg.(map[string]interface{}); msg["request_id"] != "r-42" {
+		t.Errorf("unexpected msg %v", msg)
+	}
+}
+
+func TestCancelledCtxSkipsAsync(t *testing.T) {
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.InfoCtx(ctx, "dropped")
+	l.InfoCtx(context.Background(), "sent")
+	l.Close()
+	if n := len(s.events()); n != 1 {
+		t.Errorf("expected 1 send got %d", n)
+	}
+}
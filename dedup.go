@@ -0,0 +1,98 @@
+package plywood
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// repeat is a run of identical events, the first of which was emitted.
+type repeat struct {
+	level  uint
+	msg    string
+	caller string
+	since  time.Time // when the first event was emitted
+	count  int       // suppressed events
+}
+
+// text returns the summary message of the run.
+func (r *repeat) text() string {
+	return r.msg + " repeated " + strconv.Itoa(r.count) + " times"
+}
+
+// deduper suppresses consecutive identical events within a window.
+type deduper struct {
+	m      sync.Mutex
+	window time.Duration
+	last   repeat
+}
+
+// check reports whether an event of msg at level should be emitted. The
+// summary of the previous run is returned once a different event arrives or
+// the window of the run elapses, nil when nothing was suppressed.
+func (d *deduper) check(level uint, msg, caller string, now time.Time) (*repeat, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	// the caller of last is only empty before the first event
+	if d.last.msg == msg && d.last.level == level && d.last.caller != "" && now.Sub(d.last.since) < d.window {
+		d.last.count++
+		return nil, false
+	}
+	var summary *repeat
+	if d.last.count > 0 {
+		r := d.last
+		summary = &r
+	}
+	d.last = repeat{level: level, msg: msg, caller: caller, since: now}
+	return summary, true
+}
+
+// pending returns the summary of the current run and starts counting the
+// suppressed events of the run again, nil when nothing was suppressed.
+func (d *deduper) pending() *repeat {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.last.count == 0 {
+		return nil
+	}
+	r := d.last
+	d.last.count = 0
+	return &r
+}
+
+// flushDedup emits the summary of the run suppressed by the deduper of l,
+// so it is not lost when no other event follows.
+func (l *Log) flushDedup() {
+	l.mu.RLock()
+	d, metric := l.dedup, l.metricHook
+	l.mu.RUnlock()
+	if d == nil {
+		return
+	}
+	if summary := d.pending(); summary != nil {
+		if metric != nil {
+			metric(summary.level)
+		}
+		l.emit(context.Background(), summary.level, "", []interface{}{summary.text()}, false, summary.caller)
+	}
+}
+
+// SetDedup suppresses consecutive identical events within window, zero turns it off.
+func SetDedup(window time.Duration) {
+	logger.SetDedup(window)
+}
+
+// SetDedup suppresses consecutive identical events, by level and formatted
+// message, within window of the first one. A "<msg> repeated N times" event
+// follows once a different event arrives or the window elapses, and on
+// Flush and Close. Zero turns deduplication off.
+func (l *Log) SetDedup(window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if window <= 0 {
+		l.dedup = nil
+		return
+	}
+	l.dedup = &deduper{window: window}
+}
@@ -0,0 +1,84 @@
+package plywood
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New("test", "production", INFO)
+	l.SetClock(func() time.Time { return now })
+	mem := l.Capture()
+	l.SetDedup(time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				l.Errorf("connection %s", "refused")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(mem.Events()); n != 1 {
+		t.Fatalf("expected the repeats suppressed got %d events", n)
+	}
+
+	l.Info("other")
+	events := mem.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected a summary and the new event got %d events", len(events))
+	}
+	if m, _ := events[1].Data.(map[string]interface{}); events[1].Severity != "E" || m["str"] != "connection refused repeated 99 times" {
+		t.Errorf("unexpected summary %+v", events[1])
+	}
+	if m, _ := events[2].Data.(map[string]interface{}); m["str"] != "other" {
+		t.Errorf("unexpected event %+v", events[2])
+	}
+
+	// the same message passes again once the window elapses
+	l.Info("other")
+	now = now.Add(2 * time.Second)
+	l.Info("other")
+	events = mem.Events()
+	if len(events) != 5 {
+		t.Fatalf("expected a summary and the event after the window got %d events", len(events))
+	}
+	if m, _ := events[3].Data.(map[string]interface{}); m["str"] != "other repeated 1 times" {
+		t.Errorf("unexpected summary %+v", events[3])
+	}
+
+	l.SetDedup(0)
+	l.Info("other")
+	l.Info("other")
+	if n := len(mem.Events()); n != 7 {
+		t.Errorf("expected dedup off got %d events", n)
+	}
+}
+
+func TestDedupClose(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+	l.SetDedup(time.Minute)
+
+	for i := 0; i < 4; i++ {
+		l.Warning("retrying")
+	}
+	l.Close()
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected the event and its summary got %d events", len(events))
+	}
+	if m, _ := events[1].Data.(map[string]interface{}); events[1].Severity != "W" || m["str"] != "retrying repeated 3 times" {
+		t.Errorf("unexpected summary %+v", events[1])
+	}
+
+	l.Flush()
+	if n := len(mem.Events()); n != 2 {
+		t.Errorf("expected the summary written once got %d events", n)
+	}
+}
@@ -0,0 +1,37 @@
+package plywood
+
+// Discard implements sender and drops every event, events are still
+// formatted on the way.
+type Discard struct{}
+
+// Send drops a log event.
+func (Discard) Send(severity, env string, data interface{}) error {
+	return nil
+}
+
+// Silence routes every event of the global logger to the discard logger, see Log.Silence.
+func Silence() {
+	logger.Silence()
+}
+
+// Silence routes every event to the discard logger, the same as
+// SetLogger("discard"), the writers of AddWriter and the loggers of
+// SetMultiLogger, SetForward and the like are skipped too. Enabling a
+// logger again undoes it, split streams are turned off and have to be set
+// again.
+func (l *Log) Silence() {
+	l.SetLogger("discard")
+}
+
+// silence turns every destination off but the discard logger, split
+// streams included as they write whatever the Enable settings.
+// The caller must hold l.mu.
+func (l *Log) silence() {
+	l.splitStreams = false
+	l.toStderr, l.toStdout, l.toFile = false, false, false
+	l.toLoggly, l.toLogglya = false, false
+	l.toWebhook, l.toWebhooka = false, false
+	l.toSyslog = false
+	l.toMemory = false
+	l.toDiscard = true
+}
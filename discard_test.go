@@ -0,0 +1,80 @@
+package plywood
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestSilence(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	mem := l.Capture()
+
+	l.Silence()
+	l.Info("quiet")
+	if stdout.Len() != 0 || len(mem.Events()) != 0 {
+		t.Errorf("expected no output got %q and %d events", stdout.String(), len(mem.Events()))
+	}
+	l.EnableStdout(true)
+	l.Info("loud")
+	if stdout.Len() == 0 {
+		t.Error("expected enabling stdout to undo Silence")
+	}
+}
+
+func TestSilenceAddedLoggers(t *testing.T) {
+	var w, stderr bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stderr"] = &Console{w: &stderr, m: &sync.Mutex{}}
+	l.AddWriter("extra", &w)
+	multi := NewMemorySender()
+	l.SetMultiLogger("fanout", multi)
+
+	l.Silence()
+	l.Info("quiet")
+	if w.Len() != 0 || len(multi.Events()) != 0 {
+		t.Errorf("expected no output got %q and %d events", w.String(), len(multi.Events()))
+	}
+	l.EnableStderr(true)
+	l.Info("loud")
+	if w.Len() == 0 || len(multi.Events()) != 1 {
+		t.Errorf("expected enabling a logger to undo Silence got %q and %d events", w.String(), len(multi.Events()))
+	}
+}
+
+func TestSilenceSplitStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	l := New("test", "production", INFO)
+	l.SetSplitStreams(true)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.Loggers["stderr"] = &Console{w: &stderr, m: &sync.Mutex{}}
+
+	l.Silence()
+	l.Info("quiet")
+	l.Error("quiet")
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Errorf("expected no output got %q and %q", stdout.String(), stderr.String())
+	}
+}
+
+func BenchmarkDiscard(b *testing.B) {
+	l := New("test", "testing", INFO)
+	l.Silence()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("event", i)
+	}
+}
+
+func BenchmarkDiscardJSON(b *testing.B) {
+	l := New("test", "testing", INFO)
+	l.Silence()
+	l.SetFormat(FormatJSON)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("event", i)
+	}
+}
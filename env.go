@@ -0,0 +1,98 @@
+package plywood
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// envSwitches are the env variables turning loggers on or off, like the
+// -plyto flags.
+var envSwitches = []struct {
+	name   string
+	enable func(l *Log, on bool)
+}{
+	{"PLYWOOD_STDERR", (*Log).EnableStderr},
+	{"PLYWOOD_STDOUT", (*Log).EnableStdout},
+	{"PLYWOOD_FILE", (*Log).EnableFile},
+	{"PLYWOOD_LOGGLY", (*Log).EnableLoggly},
+	{"PLYWOOD_LOGGLY_ASYNC", (*Log).EnableLogglyAsync},
+	{"PLYWOOD_LOGGLY_BLOCK", func(l *Log, on bool) {
+		policy := Drop
+		if on {
+			policy = Block
+		}
+		l.SetAsyncOverflowPolicy(policy)
+	}},
+	{"PLYWOOD_WEBHOOK", (*Log).EnableWebhook},
+	{"PLYWOOD_WEBHOOK_ASYNC", (*Log).EnableWebhookAsync},
+	{"PLYWOOD_SYSLOG", (*Log).EnableSyslog},
+}
+
+// ConfigureFromEnv configures the global logger from env variables, see Log.ConfigureFromEnv.
+func ConfigureFromEnv() error {
+	return logger.ConfigureFromEnv()
+}
+
+// ConfigureFromEnv configures l from the PLYWOOD_ env variables, for
+// programs not parsing flags. Only the variables set are applied so they
+// can be mixed with flags, a later flag.Parse wins.
+//
+//	PLYWOOD_LEVEL           level by name or number, like -plylevel
+//	PLYWOOD_ENV             environment, like -plyenv
+//	PLYWOOD_TIME_THRESHOLD  TimeTrack threshold in milliseconds
+//	PLYWOOD_LOGGLY_TOKEN    loggly customer token
+//	PLYWOOD_LOGGLY_HOST     loggly host
+//	PLYWOOD_STDERR, PLYWOOD_STDOUT, PLYWOOD_FILE, PLYWOOD_LOGGLY,
+//	PLYWOOD_LOGGLY_ASYNC, PLYWOOD_LOGGLY_BLOCK, PLYWOOD_WEBHOOK,
+//	PLYWOOD_WEBHOOK_ASYNC, PLYWOOD_SYSLOG
+//	                        true or false, anything strconv.ParseBool takes
+//
+// An invalid value is skipped and the first one is returned as an error,
+// the other variables are still applied.
+func (l *Log) ConfigureFromEnv() error {
+	var first error
+	fail := func(name, value string, err error) {
+		if first == nil {
+			first = fmt.Errorf("%s=%q: %v", name, value, err)
+		}
+	}
+
+	if v, ok := os.LookupEnv("PLYWOOD_LEVEL"); ok {
+		var level Level
+		if err := level.Set(v); err != nil {
+			fail("PLYWOOD_LEVEL", v, err)
+		} else {
+			l.SetLevel(uint(level))
+		}
+	}
+	if v, ok := os.LookupEnv("PLYWOOD_ENV"); ok {
+		l.SetEnv(v)
+	}
+	if v, ok := os.LookupEnv("PLYWOOD_TIME_THRESHOLD"); ok {
+		if t, err := strconv.ParseFloat(v, 64); err != nil {
+			fail("PLYWOOD_TIME_THRESHOLD", v, err)
+		} else {
+			l.SetTimeTrackThreshold(t)
+		}
+	}
+	if v, ok := os.LookupEnv("PLYWOOD_LOGGLY_HOST"); ok {
+		l.SetLogglyHost(v)
+	}
+	if v, ok := os.LookupEnv("PLYWOOD_LOGGLY_TOKEN"); ok {
+		l.SetLogglyToken(v)
+	}
+	for _, sw := range envSwitches {
+		v, ok := os.LookupEnv(sw.name)
+		if !ok {
+			continue
+		}
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			fail(sw.name, v, err)
+			continue
+		}
+		sw.enable(l, on)
+	}
+	return first
+}
@@ -0,0 +1,56 @@
+package plywood
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigureFromEnv(t *testing.T) {
+	t.Setenv("PLYWOOD_LEVEL", "warning")
+	t.Setenv("PLYWOOD_ENV", "staging")
+	t.Setenv("PLYWOOD_STDOUT", "true")
+	t.Setenv("PLYWOOD_TIME_THRESHOLD", "12.5")
+	t.Setenv("PLYWOOD_LOGGLY_TOKEN", "token")
+	l := New("test", "development", INFO)
+	l.toFile = true
+
+	if err := l.ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	c := l.Config()
+	if c.Level != WARNING || c.Env != "staging" || !c.ToStdout || c.TimeTrackThreshold != 12.5 {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if _, ok := l.Loggers["loggly"].(*Loggly); !ok {
+		t.Error("expected the loggly logger to be created")
+	}
+	if !c.ToFile || c.ToStderr {
+		t.Errorf("expected unset variables to leave the config %+v", c)
+	}
+}
+
+func TestConfigureFromEnvInvalid(t *testing.T) {
+	t.Setenv("PLYWOOD_LEVEL", "loud")
+	t.Setenv("PLYWOOD_STDERR", "true")
+	l := New("test", "development", INFO)
+
+	err := l.ConfigureFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "PLYWOOD_LEVEL") {
+		t.Errorf("expected a PLYWOOD_LEVEL error got %v", err)
+	}
+	if c := l.Config(); c.Level != INFO || !c.ToStderr {
+		t.Errorf("expected the valid variables applied %+v", c)
+	}
+}
+
+func TestConfigureFromEnvGlobal(t *testing.T) {
+	old := TimeTrackThreshold()
+	defer SetTimeTrackThreshold(old)
+	t.Setenv("PLYWOOD_TIME_THRESHOLD", "99")
+	if err := ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if TimeTrackThreshold() != 99 {
+		t.Errorf("expected the global threshold set got %v", TimeTrackThreshold())
+	}
+}
@@ -0,0 +1,110 @@
+package plywood
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WithFields returns a child logger that adds fields to every event it logs.
+// The child shares the senders of l and starts with a copy of its
+// configuration, fields of l are combined with fields, the latter winning.
+func (l *Log) WithFields(fields map[string]interface{}) *Log {
+	l.mu.RLock()
+	child := *l
+	l.mu.RUnlock()
+	child.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	for k, v := range fields {
+		child.fields[k] = v
+	}
+	return &child
+}
+
+// WithFields returns a child of the global logger that adds fields to every event.
+func WithFields(fields map[string]interface{}) *Log {
+	return logger.WithFields(fields)
+}
+
+// SetGlobalFields sets fields added to every event of the global logger, see Log.SetGlobalFields.
+func SetGlobalFields(fields map[string]interface{}) {
+	logger.SetGlobalFields(fields)
+}
+
+// SetGlobalFields sets fields added to every event, like the service
+// version or region. They replace the global fields set before and lose
+// to fields of the same key from WithFields or the call site. Like the
+// rest of the configuration they are copied by WithFields, so set them
+// at init.
+func (l *Log) SetGlobalFields(fields map[string]interface{}) {
+	globals := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		globals[k] = v
+	}
+	l.mu.Lock()
+	l.globalFields = globals
+	l.mu.Unlock()
+}
+
+// withGlobalFields returns a child logger with globals under the fields of l.
+func (l *Log) withGlobalFields(globals map[string]interface{}) *Log {
+	l.mu.RLock()
+	child := *l
+	l.mu.RUnlock()
+	child.fields = make(map[string]interface{}, len(globals)+len(l.fields))
+	for k, v := range globals {
+		child.fields[k] = v
+	}
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	return &child
+}
+
+// withFields returns the loggly message for data with the fields of l
+// merged in. Keys of a map message take precedence over the fields.
+func (l *Log) withFields(data interface{}) interface{} {
+	if len(l.fields) == 0 {
+		return data
+	}
+	m := make(map[string]interface{}, len(l.fields))
+	for k, v := range l.fields {
+		m[k] = v
+	}
+	if msg, ok := logglyMsg(data).(map[string]interface{}); ok {
+		for k, v := range msg {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// formatFields renders fields as " key=value" pairs sorted by key.
+func formatFields(fields map[string]interface{}) string {
+	return string(appendFields(nil, fields))
+}
+
+// appendFields appends fields as formatFields renders them to b.
+func appendFields(b []byte, fields map[string]interface{}) []byte {
+	if len(fields) == 0 {
+		return b
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b = append(b, ' ')
+		b = append(b, k...)
+		b = append(b, '=')
+		if v, ok := fields[k].(string); ok {
+			b = append(b, v...)
+		} else {
+			b = append(b, fmt.Sprint(fields[k])...)
+		}
+	}
+	return b
+}
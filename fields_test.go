@@ -0,0 +1,96 @@
+package plywood
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithFields(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	var buf bytes.Buffer
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	child := l.WithFields(map[string]interface{}{"request_id": "abc", "user_id": 7})
+	grandchild := child.WithFields(map[string]interface{}{"user_id": 8, "step": "auth"})
+	child.Infof("hello %s", "world")
+	grandchild.Info(map[string]interface{}{"custom": 1.5})
+	l.Info("plain")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], " request_id=abc user_id=7] hello world") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], " request_id=abc step=auth user_id=8] ") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+	if strings.Contains(lines[2], "request_id") {
+		t.Errorf("parent logged child fields %q", lines[2])
+	}
+
+	posts := r.posts(t)
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts got %d", len(posts))
+	}
+	msg := posts[0].Msg.(map[string]interface{})
+	if msg["request_id"] != "abc" || msg["user_id"] != 7.0 || msg["str"] != "hello world" {
+		t.Errorf("unexpected msg %v", msg)
+	}
+	msg = posts[1].Msg.(map[string]interface{})
+	if msg["request_id"] != "abc" || msg["user_id"] != 8.0 || msg["step"] != "auth" || msg["custom"] != 1.5 {
+		t.Errorf("unexpected msg %v", msg)
+	}
+	msg = posts[2].Msg.(map[string]interface{})
+	if _, ok := msg["request_id"]; ok {
+		t.Errorf("parent posted child fields %v", msg)
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	var buf bytes.Buffer
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+	l.SetGlobalFields(map[string]interface{}{"service": "api", "region": "eu"})
+
+	l.SetFormat(FormatJSON)
+	l.Info("json")
+	l.SetFormat(FormatLogfmt)
+	l.WithFields(map[string]interface{}{"region": "us"}).Info("logfmt")
+	l.Infow("call", "service", "worker")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"service":"api"`) || !strings.Contains(lines[0], `"region":"eu"`) {
+		t.Errorf("expected global fields in json %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "service=api") || !strings.Contains(lines[1], "region=us") {
+		t.Errorf("expected WithFields to win in logfmt %q", lines[1])
+	}
+
+	posts := r.posts(t)
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts got %d", len(posts))
+	}
+	msg := posts[0].Msg.(map[string]interface{})
+	if msg["service"] != "api" || msg["region"] != "eu" {
+		t.Errorf("expected global fields got %v", msg)
+	}
+	msg = posts[2].Msg.(map[string]interface{})
+	if msg["service"] != "worker" || msg["region"] != "eu" {
+		t.Errorf("expected the call site field to win got %v", msg)
+	}
+}
@@ -0,0 +1,108 @@
+package plywood
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// File implements sender and logs events to a file. The file is opened
+// on first use and the handle is reused for every following event.
+type File struct {
+	path string
+	f    *os.File
+	m    *sync.Mutex
+}
+
+// defaultFilePath is where the file logger writes when no path is set.
+func defaultFilePath() string {
+	return "./" + program + ".log"
+}
+
+// newFile creates a file sender for path without opening it.
+func newFile(path string) *File {
+	return &File{
+		path: path,
+		m:    &sync.Mutex{},
+	}
+}
+
+// open opens the file for appending if it is not open already.
+// The caller must hold f.m.
+func (f *File) open() error {
+	if f.f != nil {
+		return nil
+	}
+	fh, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	f.f = fh
+	return nil
+}
+
+// Send a log event to the file.
+func (f *File) Send(severity, env string, data interface{}) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if err := f.open(); err != nil {
+		return err
+	}
+	if d, ok := data.(string); ok {
+		if _, err := io.WriteString(f.f, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying file handle. A later Send reopens it.
+func (f *File) Close() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
+
+// Flush commits the written events to disk.
+func (f *File) Flush() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	return f.f.Sync()
+}
+
+// SetFileLogger opens path as the destination of the file logger.
+func SetFileLogger(path string) error {
+	return logger.SetFileLogger(path)
+}
+
+// SetFileLogger opens path as the destination of the file logger.
+func (l *Log) SetFileLogger(path string) error {
+	f := newFile(path)
+	f.m.Lock()
+	err := f.open()
+	f.m.Unlock()
+	if err != nil {
+		return err
+	}
+	l.setFile(f)
+	return nil
+}
+
+// setFile replaces the file logger with s and closes the old one.
+func (l *Log) setFile(s Sender) {
+	l.mu.Lock()
+	old, _ := l.Loggers["file"].(io.Closer)
+	l.Loggers["file"] = s
+	l.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
@@ -0,0 +1,73 @@
+package plywood
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	l := New("test", "testing", INFO)
+	if err := l.SetFileLogger(path); err != nil {
+		t.Fatal(err)
+	}
+	l.toFile = true
+	l.Info("first")
+	l.Infof("second %d", 2)
+	l.Debug("hidden")
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %d: %q", len(lines), b)
+	}
+	if !strings.HasPrefix(lines[0], "I") || !strings.HasSuffix(lines[0], "] first") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "] second 2") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+}
+
+func TestFileLoggerBadPath(t *testing.T) {
+	l := New("test", "testing", INFO)
+	if err := l.SetFileLogger(filepath.Join(os.DevNull, "nope", "test.log")); err == nil {
+		t.Error("expected error opening bad path")
+	}
+	if _, ok := l.Loggers["file"]; ok {
+		t.Error("file logger registered after failed open")
+	}
+}
+
+func TestFileSendWriteError(t *testing.T) {
+	f, err := ioutil.TempFile("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	// a read only handle makes every write fail
+	ro, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := newFile(f.Name())
+	fs.f = ro
+	defer fs.Close()
+	if err := fs.Send("I", "testing", "line\n"); err == nil {
+		t.Error("expected write error")
+	}
+}
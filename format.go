@@ -0,0 +1,192 @@
+package plywood
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Console and file output formats.
+const (
+	FormatText   = "text"   // header followed by the message
+	FormatJSON   = "json"   // one json object per line, keys sorted
+	FormatLogfmt = "logfmt" // key=value pairs, fields sorted by key
+)
+
+// SetFormat sets the output format of the global logger's console and file loggers.
+func SetFormat(format string) error {
+	return logger.SetFormat(format)
+}
+
+// SetFormat sets the output format of the console and file loggers,
+// FormatText, FormatJSON or FormatLogfmt.
+func (l *Log) SetFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON, FormatLogfmt:
+		l.mu.Lock()
+		l.format = format
+		l.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// defaultLineSeparator returns the line separator of the platform.
+func defaultLineSeparator() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// SetLineSeparator sets the separator ending the lines of the global logger, see Log.SetLineSeparator.
+func SetLineSeparator(sep string) {
+	logger.SetLineSeparator(sep)
+}
+
+// SetLineSeparator sets the separator ending every console and file line,
+// "\n", or "\r\n" on windows, by default. Use "\x00" for NUL delimited
+// records. An empty sep returns to the default.
+func (l *Log) SetLineSeparator(sep string) {
+	if sep == "" {
+		sep = defaultLineSeparator()
+	}
+	l.mu.Lock()
+	l.lineSep = sep
+	l.mu.Unlock()
+}
+
+// SetPrefix sets the prefix of the global logger's console and file messages, see Log.SetPrefix.
+func SetPrefix(prefix string) {
+	logger.SetPrefix(prefix)
+}
+
+// SetPrefix sets a string like "[worker-3]" written between the header and
+// the message of every console and file line, and as the prefix field of
+// the json and logfmt lines. An empty prefix turns it off.
+func (l *Log) SetPrefix(prefix string) {
+	l.mu.Lock()
+	l.prefix = prefix
+	l.mu.Unlock()
+}
+
+// prefixed returns msg after the prefix of l.
+// The caller must hold l.mu.
+func (l *Log) prefixed(msg string) string {
+	if l.prefix == "" {
+		return msg
+	}
+	return l.prefix + " " + msg
+}
+
+// textLine ends a text formatted line with the line separator, a non
+// empty stack follows on the next lines before it.
+// The caller must hold l.mu.
+func (l *Log) textLine(line, stack string) string {
+	if stack != "" {
+		line += "\n" + strings.TrimSuffix(stack, "\n")
+	}
+	return line + l.lineSep
+}
+
+// jsonLine renders an event as a json object mirroring LogglyPost, with
+// the fields of l as top level keys. A single map message is kept as is.
+// A non empty stack is added under the stack key. encoding/json writes
+// map keys sorted, so lines of the same event are byte for byte equal.
+func (l *Log) jsonLine(severity, caller, fmtStr string, msg []interface{}, stack string) string {
+	m := make(map[string]interface{}, len(l.fields)+8)
+	for k, v := range l.fields {
+		m[k] = v
+	}
+	m["timestamp"] = iso8601(l.now().UTC())
+	m["env"] = l.Env
+	m["app"] = l.App
+	m["caller"] = caller
+	m["host"] = l.Host
+	m["pid"] = pid
+	m["level"] = severity
+	m["msg"] = text(fmtStr, msg)
+	if l.prefix != "" {
+		m["prefix"] = l.prefix
+	}
+	if stack != "" {
+		m["stack"] = stack
+	}
+	if fmtStr == "" && len(msg) == 1 {
+		if k, v := logglyValue(msg); k == "" || k == "array" {
+			m["msg"] = v
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		// fall back to the text message, unmarshalable values are dropped
+		m["msg"] = text(fmtStr, msg)
+		for k := range l.fields {
+			delete(m, k)
+		}
+		b, _ = json.Marshal(m)
+	}
+	return string(b) + l.lineSep
+}
+
+// logfmtLine renders an event as logfmt with the app, host and pid of
+// LogglyPost, the fields of l follow the message in sorted key order and
+// a non empty stack comes last.
+func (l *Log) logfmtLine(level uint, caller, fmtStr string, msg []interface{}, stack string) string {
+	var b strings.Builder
+	b.WriteString("level=" + LevelString(level))
+	b.WriteString(" ts=" + iso8601(l.now().UTC()))
+	b.WriteString(" app=" + logfmtValue(l.App))
+	b.WriteString(" host=" + logfmtValue(l.Host))
+	b.WriteString(" pid=" + strconv.Itoa(pid))
+	b.WriteString(" caller=" + logfmtValue(caller))
+	b.WriteString(" msg=" + logfmtValue(text(fmtStr, msg)))
+	if l.prefix != "" {
+		b.WriteString(" prefix=" + logfmtValue(l.prefix))
+	}
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(" " + k + "=" + logfmtValue(fmt.Sprint(l.fields[k])))
+	}
+	if stack != "" {
+		b.WriteString(" stack=" + logfmtValue(stack))
+	}
+	b.WriteString(l.lineSep)
+	return b.String()
+}
+
+// logfmtValue quotes v when it is empty or holds spaces, quotes, equal
+// signs or control characters.
+func logfmtValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	for _, r := range v {
+		if r <= ' ' || r == '"' || r == '=' || r == '\\' || r == 0x7f {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
+
+// text renders the message of an event, the operands of an unformatted one
+// are joined with spaces, strings included, as sprintln does.
+func text(fmtStr string, msg []interface{}) string {
+	if fmtStr == "" {
+		return sprintln(msg)
+	}
+	return fmt.Sprintf(fmtStr, msg...)
+}
+
+// sprintln renders msg as fmt.Sprintln does, without the newline.
+func sprintln(msg []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
+}
@@ -0,0 +1,231 @@
+package plywood
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout = true
+	if err := l.SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	l.WithFields(map[string]interface{}{"request_id": "abc"}).Warningf("disk %d%% full", 90)
+	l.Info(map[string]interface{}{"custom": 1.5})
+	l.Info([]int{1, 2})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %q", buf.String())
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatalf("%s: %s", err, lines[0])
+	}
+	expected := map[string]interface{}{
+		"level":      "W",
+		"msg":        "disk 90% full",
+		"host":       l.Host,
+		"app":        "test",
+		"env":        "testing",
+		"pid":        float64(os.Getpid()),
+		"request_id": "abc",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("%s: expected %v got %v", k, v, m[k])
+		}
+	}
+	if ts, _ := m["timestamp"].(string); len(ts) != len("2014-01-02T10:20:30.000Z") {
+		t.Errorf("unexpected timestamp %v", m["timestamp"])
+	}
+	if caller, _ := m["caller"].(string); caller == "" || caller == "???" {
+		t.Errorf("unexpected caller %v", m["caller"])
+	}
+
+	m = nil
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatalf("%s: %s", err, lines[1])
+	}
+	if msg, ok := m["msg"].(map[string]interface{}); !ok || msg["custom"] != 1.5 {
+		t.Errorf("unexpected msg %v", m["msg"])
+	}
+	if !strings.Contains(lines[2], `"msg":[1,2]`) {
+		t.Errorf("expected the slice as a json array got %s", lines[2])
+	}
+}
+
+// TestFormatGolden logs the same events in the json and logfmt formats
+// repeatedly, their keys must come out in the same order every time.
+func TestFormatGolden(t *testing.T) {
+	golden := map[string]string{
+		FormatJSON: `{"app":"test","b":2,"c":3,"caller":"-","env":"testing","host":"box","level":"I",` +
+			`"msg":{"m":1,"x":2,"y":3,"z":4},"pid":` + fmt.Sprint(pid) + `,"timestamp":"2014-01-02T10:20:30.000Z","z":"last"}` + "\n" +
+			`{"a":1,"app":"test","b":2,"c":3,"caller":"-","env":"testing","host":"box","level":"W",` +
+			`"msg":"plain","pid":` + fmt.Sprint(pid) + `,"timestamp":"2014-01-02T10:20:30.000Z","z":"last"}` + "\n",
+		FormatLogfmt: `level=info ts=2014-01-02T10:20:30.000Z app=test host=box pid=` + fmt.Sprint(pid) +
+			` caller=- msg="map[m:1 x:2 y:3 z:4]" b=2 c=3 z=last` + "\n" +
+			`level=warning ts=2014-01-02T10:20:30.000Z app=test host=box pid=` + fmt.Sprint(pid) +
+			` caller=- msg=plain a=1 b=2 c=3 z=last` + "\n",
+	}
+	for format, want := range golden {
+		for i := 0; i < 20; i++ {
+			var buf bytes.Buffer
+			l := New("test", "testing", INFO)
+			l.Host = "box"
+			l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+			l.toStdout = true
+			l.SetCaller(false)
+			l.SetClock(func() time.Time { return time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC) })
+			if err := l.SetFormat(format); err != nil {
+				t.Fatal(err)
+			}
+
+			fl := l.WithFields(map[string]interface{}{"z": "last", "c": 3, "b": 2})
+			fl.Info(map[string]interface{}{"y": 3, "m": 1, "z": 4, "x": 2})
+			fl.WithFields(map[string]interface{}{"a": 1}).Warning("plain")
+			if buf.String() != want {
+				t.Fatalf("%s run %d: expected\n%s\ngot\n%s", format, i, want, buf.String())
+			}
+		}
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	l := New("test", "testing", INFO)
+	if err := l.SetFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if err := l.SetFormat(FormatText); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout = true
+	if err := l.SetFormat(FormatLogfmt); err != nil {
+		t.Fatal(err)
+	}
+
+	l.WithFields(map[string]interface{}{
+		"query": "a=b",
+		"user":  "bob",
+		"empty": "",
+	}).Info("user said \"hi there\"")
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "level=info ts=") {
+		t.Errorf("unexpected line %q", line)
+	}
+	if want := fmt.Sprintf(" app=test host=%s pid=%d caller=", logfmtValue(l.Host), os.Getpid()); !strings.Contains(line, want) {
+		t.Errorf("expected%s in %q", want, line)
+	}
+	if !strings.Contains(line, ` msg="user said \"hi there\""`) {
+		t.Errorf("message not quoted %q", line)
+	}
+	if !strings.HasSuffix(line, ` empty="" query="a=b" user=bob`+"\n") {
+		t.Errorf("fields not sorted and quoted %q", line)
+	}
+}
+
+func TestLogfmtValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"", `""`},
+		{"two words", `"two words"`},
+		{"k=v", `"k=v"`},
+		{`say "x"`, `"say \"x\""`},
+		{"line\nbreak", `"line\nbreak"`},
+	}
+	for _, tt := range tests {
+		if v := logfmtValue(tt.value); v != tt.expected {
+			t.Errorf("%q: expected %s got %s", tt.value, tt.expected, v)
+		}
+	}
+}
+
+func TestSetLineSeparator(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout, l.toStderr = true, false
+
+	l.SetLineSeparator("\r\n")
+	l.Info("crlf")
+	l.SetLineSeparator("\x00")
+	l.Info("nul")
+	records := strings.Split(strings.TrimSuffix(buf.String(), "\x00"), "\r\n")
+	if len(records) != 2 || !strings.HasSuffix(records[0], "] crlf") || !strings.HasSuffix(records[1], "] nul") {
+		t.Errorf("unexpected records %q", records)
+	}
+
+	for _, format := range []string{FormatJSON, FormatLogfmt} {
+		buf.Reset()
+		l.SetFormat(format)
+		l.Info("one")
+		l.Info("two")
+		if n := strings.Count(buf.String(), "\x00"); n != 2 || strings.Contains(buf.String(), "\n") || !strings.HasSuffix(buf.String(), "\x00") {
+			t.Errorf("%s: expected 2 NUL separated records got %q", format, buf.String())
+		}
+	}
+
+	l.SetLineSeparator("")
+	if sep := l.Config().LineSeparator; sep != defaultLineSeparator() {
+		t.Errorf("expected the default separator got %q", sep)
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetPrefix("[worker-3]")
+
+	l.Info("text")
+	if !strings.HasSuffix(buf.String(), "] [worker-3] text\n") {
+		t.Errorf("expected the prefix before the message got %q", buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	l.Info("json")
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("%s: %s", err, buf.String())
+	}
+	if m["prefix"] != "[worker-3]" || m["msg"] != "json" {
+		t.Errorf("expected a prefix field got %v", m)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatLogfmt)
+	l.Info("logfmt")
+	if !strings.Contains(buf.String(), " msg=logfmt prefix=[worker-3]") {
+		t.Errorf("expected a prefix field got %q", buf.String())
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.SetPrefix("")
+	l.Info("plain")
+	if !strings.HasSuffix(buf.String(), "] plain\n") {
+		t.Errorf("expected no prefix got %q", buf.String())
+	}
+}
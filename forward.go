@@ -0,0 +1,94 @@
+package plywood
+
+import (
+	"context"
+	"fmt"
+)
+
+// Forward implements sender and logs every event again on another log,
+// through its own loggers, levels and fields.
+type Forward struct {
+	target *Log
+}
+
+// Send forwards a log event to the target log.
+func (f *Forward) Send(severity, env string, data interface{}) error {
+	return f.sendCallerCtx(context.Background(), severity, env, "", data)
+}
+
+// sendCaller forwards a log event logged by caller.
+func (f *Forward) sendCaller(severity, env, caller string, data interface{}) error {
+	return f.sendCallerCtx(context.Background(), severity, env, caller, data)
+}
+
+// sendCallerCtx forwards a log event logged by caller, ctx bounds the
+// synchronous sends of the target log.
+func (f *Forward) sendCallerCtx(ctx context.Context, severity, env, caller string, data interface{}) error {
+	msg, ok := data.([]interface{})
+	if !ok {
+		msg = []interface{}{data}
+	}
+	return f.target.send(ctx, severityLevel(severity), "", msg, false, caller)
+}
+
+// SetForward adds a logger forwarding events to target on the global logger, see Log.SetForward.
+func SetForward(name string, target *Log, level uint) error {
+	return logger.SetForward(name, target, level)
+}
+
+// SetForward adds a logger named name logging the events of l at level
+// and above again on target, e.g. a component log with its own level
+// feeding an aggregating parent. target applies its own loggers, levels
+// and fields, the caller of the event is kept. Like the loggers of
+// SetMultiLogger it is on from the start. It fails when target already
+// forwards to l, directly or through other logs, as the events would
+// loop forever.
+func (l *Log) SetForward(name string, target *Log, level uint) error {
+	if target == nil {
+		return fmt.Errorf("forward target not set")
+	}
+	if lineLogger(name) || postLogger(name) {
+		return fmt.Errorf("%s is a built in logger", name)
+	}
+	if target == l || target.forwardsTo(l, map[*Log]bool{}) {
+		return fmt.Errorf("forwarding %s to its target makes a cycle", name)
+	}
+	l.SetLoggerLevel(name, level)
+	l.setPostLogger(name, &Forward{target: target})
+	return nil
+}
+
+// forwardsTo reports whether l forwards events to target, directly or
+// through the logs it forwards to. seen holds the logs already walked.
+func (l *Log) forwardsTo(target *Log, seen map[*Log]bool) bool {
+	if seen[l] {
+		return false
+	}
+	seen[l] = true
+	var next []*Log
+	l.mu.RLock()
+	for _, s := range l.Loggers {
+		next = appendForwards(next, s)
+	}
+	l.mu.RUnlock()
+	for _, t := range next {
+		if t == target || t.forwardsTo(target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendForwards appends the target logs of s, a Forward or a
+// MultiSender holding some, to logs.
+func appendForwards(logs []*Log, s Sender) []*Log {
+	switch s := s.(type) {
+	case *Forward:
+		return append(logs, s.target)
+	case MultiSender:
+		for _, m := range s {
+			logs = appendForwards(logs, m)
+		}
+	}
+	return logs
+}
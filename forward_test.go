@@ -0,0 +1,55 @@
+package plywood
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	parent := New("parent", "production", INFO)
+	mem := parent.Capture()
+	child := New("child", "production", DEBUG)
+	if err := child.SetForward("parent", parent, WARNING); err != nil {
+		t.Fatal(err)
+	}
+
+	child.Info("below the forward level")
+	child.Warning("disk", "full")
+	child.WithFields(map[string]interface{}{"component": "db"}).Error(map[string]interface{}{"code": 7})
+	parent.SetLevel(ERROR)
+	child.Warning("below the parent level")
+
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 forwarded events got %v", events)
+	}
+	if events[0].Severity != "W" || !reflect.DeepEqual(events[0].Data, map[string]interface{}{"str": "disk full"}) {
+		t.Errorf("unexpected event %v", events[0])
+	}
+	want := map[string]interface{}{"component": "db", "code": 7}
+	if events[1].Severity != "E" || !reflect.DeepEqual(events[1].Data, want) {
+		t.Errorf("expected %v got %v", want, events[1])
+	}
+}
+
+func TestForwardCycle(t *testing.T) {
+	a := New("a", "production", INFO)
+	b := New("b", "production", INFO)
+	c := New("c", "production", INFO)
+	if err := a.SetForward("a", a, INFO); err == nil {
+		t.Error("expected an error forwarding to itself")
+	}
+	if err := a.SetForward("b", b, INFO); err != nil {
+		t.Fatal(err)
+	}
+	b.SetMultiLogger("fanout", &Forward{target: c})
+	if err := c.SetForward("a", a, INFO); err == nil {
+		t.Error("expected an error for the cycle a > b > c > a")
+	}
+	if err := c.SetForward("loggly", b, INFO); err == nil {
+		t.Error("expected an error for a built in logger name")
+	}
+	if err := a.SetForward("c", c, INFO); err != nil {
+		t.Errorf("expected a second path to c to be allowed got %v", err)
+	}
+}
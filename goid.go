@@ -0,0 +1,38 @@
+package plywood
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the id of the calling goroutine parsed from the
+// "goroutine 18 [running]:" header of its stack, 0 if it can't be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// SetGoroutineID adds the goroutine id of the global logger's events, see Log.SetGoroutineID.
+func SetGoroutineID(on bool) {
+	logger.SetGoroutineID(on)
+}
+
+// SetGoroutineID adds the id of the logging goroutine to every event as
+// the goid field. Reading it formats the stack header and copies the
+// fields of l for each event, a few microseconds best left off outside
+// of debugging.
+func (l *Log) SetGoroutineID(on bool) {
+	l.mu.Lock()
+	l.goroutineID = on
+	l.mu.Unlock()
+}
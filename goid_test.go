@@ -0,0 +1,53 @@
+package plywood
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	l := New("test", "testing", INFO)
+	mem := l.Capture()
+	l.SetGoroutineID(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("hello")
+		}()
+	}
+	wg.Wait()
+
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+	ids := map[interface{}]bool{}
+	for _, ev := range events {
+		m, _ := ev.Data.(map[string]interface{})
+		id, ok := m["goid"].(uint64)
+		if !ok || id == 0 {
+			t.Fatalf("expected a goid in %+v", ev)
+		}
+		ids[id] = true
+	}
+	if len(ids) != 2 {
+		t.Errorf("expected distinct goids got %v", ids)
+	}
+	if goroutineID() == 0 {
+		t.Error("expected the id of the test goroutine")
+	}
+
+	var buf bytes.Buffer
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.Info("header")
+	if want := " goid=" + strconv.FormatUint(goroutineID(), 10) + "] header\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("expected %q in the header got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+package plywood
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHeaderFormat is the console layout matching header.
+const DefaultHeaderFormat = "{level}{pid} {time} {caller}{fields}] {msg}"
+
+// headerPart is a literal piece of a header format or, when token is
+// set, a placeholder.
+type headerPart struct {
+	literal string
+	token   string
+}
+
+// headerTokens are the placeholders of a header format.
+var headerTokens = map[string]bool{
+	"level": true, "pid": true, "time": true, "caller": true,
+	"fields": true, "msg": true, "app": true, "env": true, "host": true,
+}
+
+// parseHeaderFormat splits format into literals and placeholders. A format
+// without {msg} gets it appended, after a space unless it ends with one.
+func parseHeaderFormat(format string) ([]headerPart, error) {
+	sep := " "
+	if strings.HasSuffix(format, " ") {
+		sep = ""
+	}
+	var parts []headerPart
+	hasMsg := false
+	for format != "" {
+		i := strings.IndexByte(format, '{')
+		if i < 0 {
+			parts = append(parts, headerPart{literal: format})
+			break
+		}
+		j := strings.IndexByte(format[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in %q", format)
+		}
+		token := format[i+1 : i+j]
+		if !headerTokens[token] {
+			return nil, fmt.Errorf("unknown placeholder {%s}", token)
+		}
+		hasMsg = hasMsg || token == "msg"
+		if i > 0 {
+			parts = append(parts, headerPart{literal: format[:i]})
+		}
+		parts = append(parts, headerPart{token: token})
+		format = format[i+j+1:]
+	}
+	if !hasMsg {
+		if sep != "" {
+			parts = append(parts, headerPart{literal: sep})
+		}
+		parts = append(parts, headerPart{token: "msg"})
+	}
+	return parts, nil
+}
+
+// formatHeader renders an event line without the trailing newline.
+// The caller must hold l.mu.
+func (l *Log) formatHeader(parts []headerPart, severity string, now time.Time, caller, msg string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.literal)
+		case "level":
+			b.WriteString(severity)
+		case "pid":
+			b.WriteString(strconv.Itoa(pid))
+		case "time":
+			b.WriteString(iso8601(now))
+		case "caller":
+			b.WriteString(caller)
+		case "fields":
+			b.WriteString(formatFields(l.fields))
+		case "msg":
+			b.WriteString(msg)
+		case "app":
+			b.WriteString(l.App)
+		case "env":
+			b.WriteString(l.Env)
+		case "host":
+			b.WriteString(l.Host)
+		}
+	}
+	return b.String()
+}
+
+// SetHeaderFormat sets the console layout of the global logger, see Log.SetHeaderFormat.
+func SetHeaderFormat(format string) error {
+	return logger.SetHeaderFormat(format)
+}
+
+// SetHeaderFormat sets the layout of text console and file events with the
+// placeholders {level} {pid} {time} {caller} {fields} {msg} {app} {env} and
+// {host}, e.g. "{time} {level} {msg}". The message follows a format without
+// {msg}, after a space unless it ends with one, unknown placeholders are an
+// error. DefaultHeaderFormat is the default.
+func (l *Log) SetHeaderFormat(format string) error {
+	parts, err := parseHeaderFormat(format)
+	if err != nil {
+		return err
+	}
+	if format == DefaultHeaderFormat {
+		parts = nil
+	}
+	l.mu.Lock()
+	l.headerFormat = parts
+	l.mu.Unlock()
+	return nil
+}
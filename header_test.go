@@ -0,0 +1,62 @@
+package plywood
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeaderFormat(t *testing.T) {
+	fixed := time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC)
+	var stdout bytes.Buffer
+	l := New("api", "production", INFO).WithFields(map[string]interface{}{"req": "r1"})
+	l.SetClock(func() time.Time { return fixed })
+	l.SetCaller(false)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{DefaultHeaderFormat, fmt.Sprintf("I%d 2014-01-02T10:20:30.000Z - req=r1] hello\n", pid)},
+		{"{time} {level} {msg}", "2014-01-02T10:20:30.000Z I hello\n"},
+		{"[{app}/{env}]{fields} ", "[api/production] req=r1 hello\n"},
+		{"{host} {app}[{pid}] ", fmt.Sprintf("%s api[%d] hello\n", l.Host, pid)},
+		{"{time} {level}", "2014-01-02T10:20:30.000Z I hello\n"},
+	}
+	for _, tt := range tests {
+		if err := l.SetHeaderFormat(tt.format); err != nil {
+			t.Fatal(err)
+		}
+		stdout.Reset()
+		l.Info("hello")
+		if stdout.String() != tt.expected {
+			t.Errorf("%s: expected %q got %q", tt.format, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestHeaderFormatDefault(t *testing.T) {
+	parts, err := parseHeaderFormat(DefaultHeaderFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New("test", "production", INFO)
+	now := timeNow()
+	fields := map[string]interface{}{"a": 1}
+	l.fields = fields
+	if got, want := l.formatHeader(parts, "W", now, "x.go:1:f", "msg"), header("W", now, "x.go:1:f", fields)+"msg"; got != want {
+		t.Errorf("expected the default format to match header %q got %q", want, got)
+	}
+}
+
+func TestHeaderFormatInvalid(t *testing.T) {
+	for _, format := range []string{"{nope} {msg}", "{level"} {
+		if err := New("test", "production", INFO).SetHeaderFormat(format); err == nil {
+			t.Errorf("%s: expected an error", format)
+		}
+	}
+}
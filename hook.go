@@ -0,0 +1,89 @@
+package plywood
+
+// Hook transforms an event before it is sent. fields holds the fields of
+// the event and its message under the msg key, as text or a copy of a
+// single map, changes to it change the event. A message left as is keeps
+// its types. Returning false drops the event.
+type Hook func(level uint, fields map[string]interface{}) (bool, error)
+
+// AddHook adds a hook to the global logger, see Log.AddHook.
+func AddHook(fn Hook) {
+	logger.AddHook(fn)
+}
+
+// AddHook adds a hook run on every event, in the order hooks were added,
+// before any logger is called. An error of a hook is written to stderr
+// and the event carries on. Child loggers keep the hooks of l at the time
+// they are created.
+func (l *Log) AddHook(fn Hook) {
+	l.mu.Lock()
+	l.hooks = append(l.hooks[:len(l.hooks):len(l.hooks)], fn)
+	l.mu.Unlock()
+}
+
+// SetMetricHook sets the metric hook of the global logger, see Log.SetMetricHook.
+func SetMetricHook(fn func(level uint)) {
+	logger.SetMetricHook(fn)
+}
+
+// SetMetricHook sets fn to be called with the level of every event sent,
+// after sampling, rate limits, hooks and dedup had their say, e.g. to count
+// events per level. fn runs on the logging goroutine so it should be as
+// cheap as incrementing a counter. A nil fn removes it.
+func (l *Log) SetMetricHook(fn func(level uint)) {
+	l.mu.Lock()
+	l.metricHook = fn
+	l.mu.Unlock()
+}
+
+// runHooks runs hooks on an event, returning the logger, format and message
+// to send it with, or false when a hook drops it. The message is kept as
+// logged, with its types, unless a hook changes the msg key. A msg field
+// of l is kept aside, hooks see the message under that key.
+func (l *Log) runHooks(hooks []Hook, level uint, fmtStr string, msg []interface{}) (*Log, string, []interface{}, bool) {
+	fields := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fieldMsg, hasFieldMsg := l.fields["msg"]
+	str := text(fmtStr, msg)
+	fields["msg"] = str
+	single := false // the message is a single map, hooks get a copy of it
+	if fmtStr == "" && len(msg) == 1 {
+		if m, ok := msg[0].(map[string]interface{}); ok {
+			cp := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				cp[k] = v
+			}
+			fields["msg"] = cp
+			single = true
+		}
+	}
+
+	for _, fn := range hooks {
+		ok, err := fn(level, fields)
+		if err != nil {
+			diag("E hook: " + err.Error() + "] \n")
+		}
+		if !ok {
+			return l, fmtStr, nil, false
+		}
+	}
+
+	m, ok := fields["msg"]
+	delete(fields, "msg")
+	if hasFieldMsg {
+		fields["msg"] = fieldMsg
+	}
+	switch s, isStr := m.(string); {
+	case !ok:
+		fmtStr, msg = "", nil
+	case single || !isStr || s != str:
+		fmtStr, msg = "", []interface{}{m}
+	}
+	l.mu.RLock()
+	child := *l
+	l.mu.RUnlock()
+	child.fields = fields
+	return &child, fmtStr, msg, true
+}
@@ -0,0 +1,129 @@
+package plywood
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHookRedact(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+	l.AddHook(func(level uint, fields map[string]interface{}) (bool, error) {
+		if _, ok := fields["password"]; ok {
+			fields["password"] = "xxx"
+		}
+		if m, ok := fields["msg"].(map[string]interface{}); ok {
+			delete(m, "password")
+		}
+		fields["region"] = "eu"
+		return true, nil
+	})
+
+	payload := map[string]interface{}{"user": "ann", "password": "hunter2"}
+	l.WithFields(map[string]interface{}{"password": "hunter2"}).Info("login")
+	l.Info(payload)
+
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+	if m, _ := events[0].Data.(map[string]interface{}); m["password"] != "xxx" || m["region"] != "eu" || m["str"] != "login" {
+		t.Errorf("unexpected field event %v", events[0].Data)
+	}
+	if m, _ := events[1].Data.(map[string]interface{}); m["password"] != nil || m["user"] != "ann" || m["region"] != "eu" {
+		t.Errorf("unexpected map event %v", events[1].Data)
+	}
+	if payload["password"] != "hunter2" {
+		t.Error("expected the logged map left untouched")
+	}
+}
+
+func TestHookDrop(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+	var order []int
+	l.AddHook(func(level uint, fields map[string]interface{}) (bool, error) {
+		order = append(order, 1)
+		return level >= WARNING, errors.New("first")
+	})
+	l.AddHook(func(level uint, fields map[string]interface{}) (bool, error) {
+		order = append(order, 2)
+		return true, nil
+	})
+
+	l.Info("dropped")
+	l.Warningf("kept %d", 1)
+
+	events := mem.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(events))
+	}
+	if m, _ := events[0].Data.(map[string]interface{}); m["str"] != "kept 1" {
+		t.Errorf("unexpected event %v", events[0].Data)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 1 || order[2] != 2 {
+		t.Errorf("expected hooks in order, stopping at a drop, got %v", order)
+	}
+}
+
+func TestHookKeepsMessage(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+	l.AddHook(func(level uint, fields map[string]interface{}) (bool, error) {
+		if fields["msg"] == "rewrite me" {
+			fields["msg"] = "rewritten"
+		}
+		return true, nil
+	})
+
+	l.Error(fmt.Errorf("save: %w", errors.New("disk full")))
+	l.Info(5)
+	l.WithFields(map[string]interface{}{"msg": "field"}).Info("event")
+	l.Info("rewrite me")
+
+	events := mem.Events()
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events got %d", len(events))
+	}
+	if m, _ := events[0].Data.(map[string]interface{}); m["error"] != "save: disk full" || m["errors"] == nil {
+		t.Errorf("expected the error chain kept got %v", events[0].Data)
+	}
+	if m, _ := events[1].Data.(map[string]interface{}); m["int"] != 5 {
+		t.Errorf("expected the int kept got %v", events[1].Data)
+	}
+	if m, _ := events[2].Data.(map[string]interface{}); m["msg"] != "field" || m["str"] != "event" {
+		t.Errorf("expected the msg field kept got %v", events[2].Data)
+	}
+	if m, _ := events[3].Data.(map[string]interface{}); m["str"] != "rewritten" {
+		t.Errorf("expected the changed message got %v", events[3].Data)
+	}
+}
+
+func TestMetricHook(t *testing.T) {
+	l := New("test", "production", INFO)
+	l.Capture()
+	counts := map[uint]int{}
+	l.SetMetricHook(func(level uint) { counts[level]++ })
+	l.AddHook(func(level uint, fields map[string]interface{}) (bool, error) {
+		return fields["msg"] != "dropped", nil
+	})
+
+	l.Debug("below the level")
+	l.Info("a")
+	l.Info("b")
+	l.Warning("c")
+	l.Errorf("d %d", 1)
+	l.Error("e")
+	l.Error("dropped")
+	l.Errorw("f", "k", 1)
+
+	if counts[DEBUG] != 0 || counts[INFO] != 2 || counts[WARNING] != 1 || counts[ERROR] != 3 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	l.SetMetricHook(nil)
+	l.Info("uncounted")
+	if counts[INFO] != 2 {
+		t.Errorf("expected the hook removed got %d", counts[INFO])
+	}
+}
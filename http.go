@@ -0,0 +1,162 @@
+package plywood
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// poster posts json bodies over http for the loggly and webhook loggers.
+type poster struct {
+	Client  *http.Client
+	m       *sync.Mutex
+	retries int
+	delay   time.Duration // base delay between retries
+	timeout time.Duration // bounds each post, whatever the client
+	headers map[string]string
+	gzip    bool // compress bodies of at least gzipMinSize bytes
+}
+
+// gzipMinSize is the smallest body worth compressing.
+const gzipMinSize = 1024
+
+// newPoster creates a poster with the http settings of l.
+func newPoster(l *Log) poster {
+	return poster{
+		Client:  newClient(l),
+		m:       &sync.Mutex{},
+		retries: l.logglyRetries,
+		delay:   l.logglyRetryDelay,
+		timeout: l.logglyTimeout,
+	}
+}
+
+// newClient creates the http client of a poster with the timeout and
+// transport of l.
+func newClient(l *Log) *http.Client {
+	c := &http.Client{Timeout: l.logglyTimeout}
+	if l.logglyTransport != nil {
+		c.Transport = l.logglyTransport
+	}
+	return c
+}
+
+// post sends the json body b to url. Connection errors and 5xx or 429
+// responses are retried with exponential backoff and jitter, for at most
+// logglyMaxRetryTime.
+func (p *poster) post(url string, b []byte) error {
+	return p.postCtx(context.Background(), url, b, nil)
+}
+
+// postCtx posts as post does with headers added to the poster's own, it
+// gives up, retries included, once ctx is done.
+func (p *poster) postCtx(ctx context.Context, url string, b []byte, headers map[string]string) error {
+	p.m.Lock()
+	retries, delay, timeout, client, gz := p.retries, p.delay, p.timeout, p.Client, p.gzip
+	p.m.Unlock()
+	if len(headers) > 0 {
+		merged := make(map[string]string, len(p.headers)+len(headers))
+		for k, v := range p.headers {
+			merged[k] = v
+		}
+		for k, v := range headers {
+			merged[k] = v
+		}
+		headers = merged
+	} else {
+		headers = p.headers
+	}
+
+	body := b
+	if gz = gz && len(b) >= gzipMinSize; gz {
+		var err error
+		if body, err = gzipBody(b); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(logglyMaxRetryTime)
+	for attempt := 0; ; attempt++ {
+		retry, err := postOnce(ctx, client, url, body, timeout, headers, gz)
+		if err == nil {
+			return nil
+		}
+		wait := backoff(delay, attempt)
+		if !retry || attempt >= retries || time.Now().Add(wait).After(deadline) || ctx.Err() != nil {
+			diag("E " + err.Error() + "] " + string(b) + "\n")
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			diag("E " + ctx.Err().Error() + "] " + string(b) + "\n")
+			return ctx.Err()
+		}
+	}
+}
+
+// gzipBody returns b gzip compressed.
+func gzipBody(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// postOnce makes a single post of b to url within timeout and ctx,
+// returning whether a failure is worth retrying. A gzipped b is sent with
+// its Content-Encoding set.
+func postOnce(ctx context.Context, client *http.Client, url string, b []byte, timeout time.Duration, headers map[string]string, gzipped bool) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("%d %s", resp.StatusCode, body)
+	}
+
+	return false, nil
+}
+
+// backoff returns the delay before retry attempt+1, doubling d per attempt
+// plus up to 50% jitter.
+func backoff(d time.Duration, attempt int) time.Duration {
+	d <<= uint(attempt)
+	if d <= 0 {
+		return 0
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
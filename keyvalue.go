@@ -0,0 +1,101 @@
+package plywood
+
+import (
+	"context"
+	"fmt"
+)
+
+// badKey is the key of a trailing value without a key.
+const badKey = "!BADKEY"
+
+// keyValues assembles msg and the alternating keys and values of kv into
+// a structured message. Keys that are not strings are formatted with
+// fmt.Sprint, a trailing value without a key is kept under badKey.
+func keyValues(msg string, kv []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(kv)/2+1)
+	m["msg"] = msg
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			m[badKey] = kv[i]
+			break
+		}
+		k, ok := kv[i].(string)
+		if !ok {
+			k = fmt.Sprint(kv[i])
+		}
+		m[k] = kv[i+1]
+	}
+	return m
+}
+
+func Debugw(msg string, kv ...interface{}) error   { return logger.logw(DEBUG, msg, kv) }
+func Infow(msg string, kv ...interface{}) error    { return logger.logw(INFO, msg, kv) }
+func Warningw(msg string, kv ...interface{}) error { return logger.logw(WARNING, msg, kv) }
+func Errorw(msg string, kv ...interface{}) error   { return logger.logw(ERROR, msg, kv) }
+
+// Debugw logs msg with the key value pairs kv, as in Debugw("saved", "id", 7).
+func (l *Log) Debugw(msg string, kv ...interface{}) error { return l.logw(DEBUG, msg, kv) }
+
+// Infow logs msg with the key value pairs kv, as in Infow("saved", "id", 7).
+func (l *Log) Infow(msg string, kv ...interface{}) error { return l.logw(INFO, msg, kv) }
+
+// Warningw logs msg with the key value pairs kv, as in Warningw("slow", "ms", 300).
+func (l *Log) Warningw(msg string, kv ...interface{}) error { return l.logw(WARNING, msg, kv) }
+
+// Errorw logs msg with the key value pairs kv, as in Errorw("failed", "err", err).
+func (l *Log) Errorw(msg string, kv ...interface{}) error { return l.logw(ERROR, msg, kv) }
+
+// logw is called by the key value logging functions.
+func (l *Log) logw(level uint, msg string, kv []interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	return l.send(context.Background(), level, "", []interface{}{keyValues(msg, kv)}, false, "")
+}
+
+func DebugFields(msg string, fields map[string]interface{}) error {
+	return logger.logFields(DEBUG, msg, fields)
+}
+func InfoFields(msg string, fields map[string]interface{}) error {
+	return logger.logFields(INFO, msg, fields)
+}
+func WarningFields(msg string, fields map[string]interface{}) error {
+	return logger.logFields(WARNING, msg, fields)
+}
+func ErrorFields(msg string, fields map[string]interface{}) error {
+	return logger.logFields(ERROR, msg, fields)
+}
+
+// DebugFields logs msg under the msg key next to fields.
+func (l *Log) DebugFields(msg string, fields map[string]interface{}) error {
+	return l.logFields(DEBUG, msg, fields)
+}
+
+// InfoFields logs msg under the msg key next to fields.
+func (l *Log) InfoFields(msg string, fields map[string]interface{}) error {
+	return l.logFields(INFO, msg, fields)
+}
+
+// WarningFields logs msg under the msg key next to fields.
+func (l *Log) WarningFields(msg string, fields map[string]interface{}) error {
+	return l.logFields(WARNING, msg, fields)
+}
+
+// ErrorFields logs msg under the msg key next to fields.
+func (l *Log) ErrorFields(msg string, fields map[string]interface{}) error {
+	return l.logFields(ERROR, msg, fields)
+}
+
+// logFields is called by the fields logging functions. The fields are
+// copied, msg takes the place of a msg field.
+func (l *Log) logFields(level uint, msg string, fields map[string]interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	m := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		m[k] = v
+	}
+	m["msg"] = msg
+	return l.send(context.Background(), level, "", []interface{}{m}, false, "")
+}
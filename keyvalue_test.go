@@ -0,0 +1,68 @@
+package plywood
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyValues(t *testing.T) {
+	tests := []struct {
+		kv   []interface{}
+		want map[string]interface{}
+	}{
+		{nil, map[string]interface{}{"msg": "m"}},
+		{[]interface{}{"a", 1, "b", "two"}, map[string]interface{}{"msg": "m", "a": 1, "b": "two"}},
+		{[]interface{}{"a", 1, "dangling"}, map[string]interface{}{"msg": "m", "a": 1, badKey: "dangling"}},
+		{[]interface{}{7, true, nil, 2.5}, map[string]interface{}{"msg": "m", "7": true, "<nil>": 2.5}},
+	}
+	for _, test := range tests {
+		if got := keyValues("m", test.kv); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("keyValues(%v): expected %v got %v", test.kv, test.want, got)
+		}
+	}
+}
+
+func TestInfow(t *testing.T) {
+	l := New("test", "testing", INFO)
+	mem := l.Capture()
+
+	l.Infow("saved", "id", 7, "user", "ann")
+	l.Errorw("failed", "odd")
+	l.Debugw("hidden", "id", 1)
+
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+	want := map[string]interface{}{"msg": "saved", "id": 7, "user": "ann"}
+	if events[0].Severity != "I" || !reflect.DeepEqual(events[0].Data, want) {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	want = map[string]interface{}{"msg": "failed", badKey: "odd"}
+	if events[1].Severity != "E" || !reflect.DeepEqual(events[1].Data, want) {
+		t.Errorf("unexpected event %+v", events[1])
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	fields := map[string]interface{}{"user": "ann", "attempt": 3}
+	l.ErrorFields("save failed", fields)
+	l.DebugFields("hidden", fields)
+
+	posts := r.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post got %d", len(posts))
+	}
+	want := map[string]interface{}{"msg": "save failed", "user": "ann", "attempt": 3.0}
+	if posts[0].Level != "E" || !reflect.DeepEqual(posts[0].Msg, want) {
+		t.Errorf("unexpected post %+v", posts[0])
+	}
+	if _, ok := fields["msg"]; ok {
+		t.Error("the fields of the caller were changed")
+	}
+}
@@ -0,0 +1,95 @@
+package plywood
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is a logging level, it implements flag.Value accepting
+// either a level name or its number.
+type Level uint
+
+// levelInfo describes a level: its severity character, used in the
+// console header and loggly posts, its name and its console color.
+type levelInfo struct {
+	char  string
+	name  string
+	color string // empty is not colored
+}
+
+// levels is indexed by level, adding a level only takes a new entry.
+var levels = []levelInfo{
+	DEBUG:   {"D", "debug", colorGray},
+	INFO:    {"I", "info", ""},
+	WARNING: {"W", "warning", colorYellow},
+	ERROR:   {"E", "error", colorRed},
+	FATAL:   {"F", "fatal", colorRed},
+}
+
+// unknownLevel describes the levels missing from levels.
+var unknownLevel = levelInfo{"?", "unknown", ""}
+
+// levelOf returns the description of level, unknownLevel when it is not
+// defined.
+func levelOf(level uint) levelInfo {
+	if level >= uint(len(levels)) {
+		return unknownLevel
+	}
+	return levels[level]
+}
+
+// ParseLevel returns the level for a case insensitive name,
+// one of debug, info, warning (or warn), error and fatal.
+func ParseLevel(s string) (uint, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warning", "warn":
+		return WARNING, nil
+	case "error":
+		return ERROR, nil
+	case "fatal":
+		return FATAL, nil
+	}
+	return 0, fmt.Errorf("unknown level %q", s)
+}
+
+// LevelString returns the name of level, "unknown" if it is not defined.
+func LevelString(level uint) string {
+	return levelOf(level).name
+}
+
+// severityLevel returns the level of a severity character.
+func severityLevel(severity string) uint {
+	for i, info := range levels {
+		if info.char == severity {
+			return uint(i)
+		}
+	}
+	return INFO
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	return LevelString(uint(l))
+}
+
+// Set parses a level name or number, numbers above FATAL are rejected.
+func (l *Level) Set(s string) error {
+	if level, err := ParseLevel(s); err == nil {
+		*l = Level(level)
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return fmt.Errorf("unknown level %q", s)
+	}
+	if n > uint64(FATAL) {
+		return fmt.Errorf("level %d out of range 0-%d", n, FATAL)
+	}
+	*l = Level(n)
+	return nil
+}
@@ -0,0 +1,158 @@
+package plywood
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level uint
+	}{
+		{"debug", DEBUG},
+		{"INFO", INFO},
+		{"Warning", WARNING},
+		{"warn", WARNING},
+		{"error", ERROR},
+		{" fatal ", FATAL},
+	}
+	for _, tt := range tests {
+		level, err := ParseLevel(tt.name)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if level != tt.level {
+			t.Errorf("%s: expected %d got %d", tt.name, tt.level, level)
+		}
+	}
+
+	for _, name := range []string{"", "verbose", "3"} {
+		if _, err := ParseLevel(name); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for _, level := range []uint{DEBUG, INFO, WARNING, ERROR, FATAL} {
+		parsed, err := ParseLevel(LevelString(level))
+		if err != nil || parsed != level {
+			t.Errorf("%d: round trip got %d %v", level, parsed, err)
+		}
+	}
+	if s := LevelString(FATAL + 1); s != "unknown" {
+		t.Errorf("expected unknown got %s", s)
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	tests := []struct {
+		arg   string
+		level Level
+	}{
+		{"0", Level(DEBUG)},
+		{"2", Level(WARNING)},
+		{"3", Level(ERROR)},
+		{"4", Level(FATAL)},
+		{"warn", Level(WARNING)},
+		{"Error", Level(ERROR)},
+	}
+	for _, tt := range tests {
+		var level uint
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var((*Level)(&level), "plylevel", "")
+		if err := fs.Parse([]string{"-plylevel", tt.arg}); err != nil {
+			t.Errorf("%s: %s", tt.arg, err)
+			continue
+		}
+		if Level(level) != tt.level {
+			t.Errorf("%s: expected %s got %s", tt.arg, tt.level, Level(level))
+		}
+	}
+
+	var l Level
+	for _, arg := range []string{"5", "-1", "loud"} {
+		if err := l.Set(arg); err == nil {
+			t.Errorf("%s: expected error", arg)
+		}
+	}
+	if s := Level(WARNING).String(); s != "warning" {
+		t.Errorf("expected warning got %s", s)
+	}
+}
+
+func TestLevelOrder(t *testing.T) {
+	levels := []uint{DEBUG, INFO, WARNING, ERROR, FATAL}
+	for i := 1; i < len(levels); i++ {
+		if levels[i-1] >= levels[i] {
+			t.Errorf("expected %s < %s", LevelString(levels[i-1]), LevelString(levels[i]))
+		}
+	}
+	chars := map[uint]rune{DEBUG: 'D', INFO: 'I', WARNING: 'W', ERROR: 'E', FATAL: 'F'}
+	for level, c := range chars {
+		if levelOf(level).char != string(c) {
+			t.Errorf("expected %c for %s got %s", c, LevelString(level), levelOf(level).char)
+		}
+		if severityLevel(string(c)) != level {
+			t.Errorf("expected %c to map back to %s", c, LevelString(level))
+		}
+	}
+}
+
+func TestLevelGetter(t *testing.T) {
+	l := New("test", "production", WARNING)
+	if l.Level() != WARNING {
+		t.Errorf("expected warning got %s", LevelString(l.Level()))
+	}
+	l.SetLevel(DEBUG)
+	if l.Level() != DEBUG {
+		t.Errorf("expected debug got %s", LevelString(l.Level()))
+	}
+}
+
+func TestSetLevelAll(t *testing.T) {
+	l := New("test", "testing", INFO)
+	mem := l.Capture()
+	l.SetLoggerLevel("memory", ERROR)
+
+	l.SetLevel(DEBUG)
+	l.Info("kept override")
+	if n := len(mem.Events()); n != 0 {
+		t.Errorf("expected SetLevel to keep the memory override got %d events", n)
+	}
+
+	l.SetLevelAll(DEBUG)
+	l.Debug("cleared override")
+	if n := len(mem.Events()); n != 1 {
+		t.Errorf("expected SetLevelAll to clear the memory override got %d events", n)
+	}
+	if l.Level() != DEBUG || len(l.Config().LoggerLevels) != 0 {
+		t.Errorf("unexpected config %+v", l.Config())
+	}
+}
+
+func TestLevelOutOfRange(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}, color: true}
+	l.toStdout, l.toStderr = true, false
+	mem := l.Capture()
+
+	if _, err := l.Writer(FATAL + 5).Write([]byte("way up\n")); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.HasPrefix(s, "?") || !strings.HasSuffix(s, "] way up\n") {
+		t.Errorf("unexpected line %q", s)
+	}
+	if events := mem.Events(); len(events) != 1 || events[0].Severity != "?" {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if LevelString(FATAL+5) != "unknown" {
+		t.Errorf("unexpected name %s", LevelString(FATAL+5))
+	}
+}
@@ -0,0 +1,58 @@
+package plywood
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// levelBody is the json body served and accepted by the level handler.
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns a handler for the level of the global logger, see Log.LevelHandler.
+func LevelHandler() http.Handler {
+	return logger.LevelHandler()
+}
+
+// LevelHandler returns a handler serving the level of l as {"level":"info"}
+// on GET and setting it on PUT or POST from the level field of a json
+// body or form, e.g. curl -X PUT -d level=debug host/debug/level.
+func (l *Log) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			name, err := requestLevel(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level, err := ParseLevel(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelBody{Level: LevelString(l.Level())})
+	})
+}
+
+// requestLevel returns the level field of a json body or form.
+func requestLevel(r *http.Request) (string, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body levelBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return "", err
+		}
+		return body.Level, nil
+	}
+	return r.FormValue("level"), nil
+}
@@ -0,0 +1,46 @@
+package plywood
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	l := New("test", "testing", INFO)
+	h := l.LevelHandler()
+
+	do := func(method, contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/level", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("GET", "", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"level":"info"}` {
+		t.Errorf("unexpected GET %d %q", w.Code, w.Body.String())
+	}
+	if w := do("PUT", "application/x-www-form-urlencoded", "level=debug"); w.Code != http.StatusOK {
+		t.Errorf("unexpected PUT %d %q", w.Code, w.Body.String())
+	}
+	if w := do("GET", "", ""); strings.TrimSpace(w.Body.String()) != `{"level":"debug"}` || l.Level() != DEBUG {
+		t.Errorf("expected debug after PUT got %q", w.Body.String())
+	}
+	if w := do("POST", "application/json", `{"level":"WARN"}`); w.Code != http.StatusOK || l.Level() != WARNING {
+		t.Errorf("unexpected json POST %d %q", w.Code, w.Body.String())
+	}
+
+	if w := do("PUT", "application/x-www-form-urlencoded", "level=loud"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid level got %d", w.Code)
+	}
+	if w := do("DELETE", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 got %d", w.Code)
+	}
+	if l.Level() != WARNING {
+		t.Errorf("level changed by a failed request %d", l.Level())
+	}
+}
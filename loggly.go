@@ -0,0 +1,743 @@
+package plywood
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logglyHost         = "logs-01.loggly.com"
+	logglyRetryDelay   = 100 * time.Millisecond
+	logglyMaxRetryTime = 10 * time.Second // cap on the time spent retrying one post
+	logglyTimeout      = 5 * time.Second
+)
+
+// Representations of the level of loggly posts.
+const (
+	LevelFormatChar = "char" // the severity character, e.g. "I"
+	LevelFormatName = "name" // the level name, e.g. "info"
+	LevelFormatNum  = "num"  // the level number, e.g. 1
+)
+
+var (
+	// logglyEnvironments are the default environments posted to loggly.
+	logglyEnvironments = map[string]bool{
+		"production": true,
+		"staging":    true,
+	}
+)
+
+// LogglyPost is the json representation of what to send
+// to loggly.
+type LogglyPost struct {
+	Timestamp string      `json:"timestamp"` // loggly iso8601 timestamp
+	Env       string      `json:"env"`       // environment
+	App       string      `json:"app"`       // application name
+	Caller    string      `json:"caller"`    // the package.function.linenum
+	Host      string      `json:"host"`      // hostname
+	Pid       int         `json:"pid"`       // processid
+	Level     interface{} `json:"level"`     // severity level, see SetLogglyLevelFormat
+	Msg       interface{} `json:"msg"`       // logging event message
+
+	msg map[string]interface{} // reused for wrapped messages of pooled posts
+}
+
+// Loggly contains the meta for sending log events to loggly.
+// Loggly implements sender.
+// With a batch size above one events are collected and posted together
+// to the bulk endpoint once the batch is full or the flush interval passes.
+type Loggly struct {
+	poster
+	url       string
+	bulkUrl   string
+	log       *Log // owning log, source of the app and host of each event
+	batch     [][]byte
+	batchSize int
+	stop      chan struct{} // stops the flush ticker
+}
+
+// logglyURL returns the loggly ingest url for token, in the format
+// https://logs-01.loggly.com/inputs/<token>/tag/<program>
+func logglyURL(host, token, tag string) string {
+	return "https://" + host + "/inputs/" + token + "/tag/" + tag
+}
+
+// logglyBulkURL returns the loggly bulk ingest url for token, in the format
+// https://logs-01.loggly.com/bulk/<token>/tag/<program>
+func logglyBulkURL(host, token, tag string) string {
+	return "https://" + host + "/bulk/" + token + "/tag/" + tag
+}
+
+// logglyTag returns the tag segment of the loggly urls, the app name
+// followed by tags, comma separated.
+func logglyTag(app string, tags []string) string {
+	return strings.Join(append([]string{app}, tags...), ",")
+}
+
+// tagsKey is the key of the per event tags in a map message.
+const tagsKey = "_tags"
+
+// eventTags removes the per event tags from the map message of data and
+// returns them comma separated. The tags are a string, comma separated
+// too, or a slice of strings. The map of the caller is left untouched.
+func eventTags(data interface{}) (interface{}, string) {
+	m, ok := data.(map[string]interface{})
+	if msg, isMsg := data.([]interface{}); isMsg && len(msg) == 1 {
+		m, ok = msg[0].(map[string]interface{})
+	}
+	if !ok {
+		return data, ""
+	}
+	v, ok := m[tagsKey]
+	if !ok {
+		return data, ""
+	}
+	var tags string
+	switch t := v.(type) {
+	case string:
+		tags = t
+	case []string:
+		tags = strings.Join(t, ",")
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, p := range t {
+			parts[i] = fmt.Sprint(p)
+		}
+		tags = strings.Join(parts, ",")
+	default:
+		tags = fmt.Sprint(t)
+	}
+	c := make(map[string]interface{}, len(m)-1)
+	for k, v := range m {
+		if k != tagsKey {
+			c[k] = v
+		}
+	}
+	return c, tags
+}
+
+// newLoggly creates the loggly sender of l.
+func newLoggly(l *Log) *Loggly {
+	s := &Loggly{
+		poster:    newPoster(l),
+		url:       logglyURL(l.logglyHost, l.logglyToken, logglyTag(l.App, l.logglyTags)),
+		bulkUrl:   logglyBulkURL(l.logglyHost, l.logglyToken, logglyTag(l.App, l.logglyTags)),
+		log:       l,
+		batchSize: l.logglyBatchSize,
+	}
+	s.gzip = l.logglyGzip
+	if l.logglyClient != nil {
+		s.Client = l.logglyClient
+	}
+	s.setFlushInterval(l.logglyFlushInterval)
+	return s
+}
+
+// Send a log event to loggly.
+func (l *Loggly) Send(severity, env string, data interface{}) error {
+	l.log.mu.RLock()
+	caller := l.log.callerName(1)
+	l.log.mu.RUnlock()
+	return l.sendCaller(severity, env, caller, data)
+}
+
+// SendCtx sends a log event to loggly as Send does, giving up on the post
+// once ctx is done.
+func (l *Loggly) SendCtx(ctx context.Context, severity, env string, data interface{}) error {
+	l.log.mu.RLock()
+	caller := l.log.callerName(1)
+	l.log.mu.RUnlock()
+	return l.sendCallerCtx(ctx, severity, env, caller, data)
+}
+
+// sendCaller sends a log event logged by caller to loggly.
+func (l *Loggly) sendCaller(severity, env, caller string, data interface{}) error {
+	return l.sendCallerCtx(context.Background(), severity, env, caller, data)
+}
+
+// sendCallerCtx sends a log event logged by caller to loggly within ctx.
+// An event with its own tags is posted on its own, outside of the batch,
+// with the tags in the X-LOGGLY-TAG header.
+func (l *Loggly) sendCallerCtx(ctx context.Context, severity, env, caller string, data interface{}) error {
+	data, tags := eventTags(data)
+	buf, err := encodePost(l.log, severity, env, caller, data)
+	if err != nil {
+		diag("E " + err.Error() + "] \n")
+		return err
+	}
+	defer putBuffer(buf)
+	b := buf.Bytes()
+
+	// Only send events of the loggly environments, production and staging
+	// by default. If not defined send to stderr
+	l.log.mu.RLock()
+	ok := l.log.logglyEnvs[env]
+	l.log.mu.RUnlock()
+	if !ok {
+		diag("E " + "env not set: " + env + "] " + string(b) + "\n")
+		return nil
+	}
+
+	if tags != "" {
+		return l.postCtx(ctx, l.url, b, map[string]string{"X-LOGGLY-TAG": tags})
+	}
+	l.m.Lock()
+	if l.batchSize <= 1 {
+		l.m.Unlock()
+		return l.postCtx(ctx, l.url, b, nil)
+	}
+	l.batch = append(l.batch, append([]byte(nil), b...))
+	if len(l.batch) < l.batchSize {
+		l.m.Unlock()
+		return nil
+	}
+	batch := l.batch
+	l.batch = nil
+	l.m.Unlock()
+	return l.postBulk(ctx, batch)
+}
+
+// Flush posts the batched events.
+func (l *Loggly) Flush() error {
+	l.m.Lock()
+	batch := l.batch
+	l.batch = nil
+	l.m.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	return l.postBulk(context.Background(), batch)
+}
+
+// Close stops the flush ticker and posts the remaining batched events.
+func (l *Loggly) Close() error {
+	l.setFlushInterval(0)
+	return l.Flush()
+}
+
+// setFlushInterval flushes the batch every d, zero stops flushing.
+func (l *Loggly) setFlushInterval(d time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	if d <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	l.stop = stop
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.Flush(); err != nil {
+					diag(err.Error() + "\n")
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// postBulk posts batch to the bulk endpoint as newline delimited json.
+func (l *Loggly) postBulk(ctx context.Context, batch [][]byte) error {
+	return l.postCtx(ctx, l.bulkUrl, bytes.Join(batch, []byte("\n")), nil)
+}
+
+var (
+	postPool   = sync.Pool{New: func() interface{} { return &LogglyPost{msg: map[string]interface{}{}} }}
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+// encodePost encodes the post of an event logged by l, without a trailing
+// newline, into a pooled buffer. Return the buffer with putBuffer once done.
+func encodePost(l *Log, severity, env, caller string, data interface{}) (*bytes.Buffer, error) {
+	p := newPost(l, severity, env, caller, data)
+	buf := bufferPool.Get().(*bytes.Buffer)
+	err := json.NewEncoder(buf).Encode(p)
+	putPost(p)
+	if err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode ends with a newline
+	return buf, nil
+}
+
+// newPost returns a pooled post of an event logged by l, return it with
+// putPost once encoded.
+func newPost(l *Log, severity, env, caller string, data interface{}) *LogglyPost {
+	l.mu.RLock()
+	now := l.now()
+	levelFormat := l.logglyLevelFormat
+	l.mu.RUnlock()
+
+	p := postPool.Get().(*LogglyPost)
+	p.Timestamp = iso8601(now.UTC())
+	p.Env = env
+	p.App = l.App
+	p.Host = l.Host
+	p.Caller = caller
+	p.Pid = pid
+	switch level := severityLevel(severity); levelFormat {
+	case LevelFormatName:
+		p.Level = LevelString(level)
+	case LevelFormatNum:
+		p.Level = level
+	default:
+		p.Level = severity
+	}
+	if k, v := logglyValue(data); k != "" {
+		p.msg[k] = v
+		p.Msg = p.msg
+	} else {
+		p.Msg = v
+	}
+	return p
+}
+
+// putPost returns a post from newPost to the pool.
+func putPost(p *LogglyPost) {
+	p.reset()
+	postPool.Put(p)
+}
+
+// putBuffer returns a buffer from encodePost to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// reset clears p, keeping its message map for reuse.
+func (p *LogglyPost) reset() {
+	for k := range p.msg {
+		delete(p.msg, k)
+	}
+	*p = LogglyPost{msg: p.msg}
+}
+
+// logglyMsg converts the data of a log event to a loggly message.
+func logglyMsg(data interface{}) interface{} {
+	k, v := logglyValue(data)
+	if k == "" {
+		return v
+	}
+	return map[string]interface{}{k: v}
+}
+
+// logglyValue returns the key and value of the loggly message of data,
+// k is empty when v is the message itself.
+func logglyValue(data interface{}) (k string, v interface{}) {
+	switch data.(type) {
+	case string:
+		return "str", data
+	case []interface{}:
+		m, _ := data.([]interface{})
+		if len(m) == 1 {
+			switch m[0].(type) {
+			case string:
+				return "str", m[0]
+			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+				return "int", m[0]
+			case float32:
+				return "float", m[0].(float32)
+			case float64:
+				return "float", m[0].(float64)
+			case bool:
+				return "bool", m[0]
+			case nil:
+				return "null", nil
+			case error:
+				if v := errorChain(m[0].(error)); v != nil {
+					return "", v
+				}
+				return "error", m[0].(error).Error()
+			case time.Duration:
+				d := m[0].(time.Duration)
+				return "", map[string]interface{}{"duration": d.String(), "ms": float64(d) / float64(time.Millisecond)}
+			case time.Time:
+				return "time", iso8601(m[0].(time.Time).UTC())
+			case map[string]interface{}:
+				return "", m[0]
+			}
+			switch rv := reflect.ValueOf(m[0]); rv.Kind() {
+			case reflect.Slice, reflect.Array:
+				if rv.Type().Elem().Kind() != reflect.Uint8 {
+					return "array", m[0]
+				}
+			case reflect.Map:
+				if rv.Type().Key().Kind() == reflect.String {
+					return "", genericMap(rv)
+				}
+			}
+			return "interface", m[0]
+		}
+		return "str", sprintln(m)
+	case map[string]interface{}:
+		return "", data
+	}
+	return "", nil
+}
+
+// genericMap copies the map with string keys rv, e.g. a map[string]string,
+// to the map[string]interface{} messages are merged with fields as.
+func genericMap(rv reflect.Value) map[string]interface{} {
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m
+}
+
+// errorChain returns the message of a wrapped err with the messages of the
+// errors it wraps, in errors.Unwrap order, and the type of the innermost
+// one. It returns nil when err wraps nothing.
+func errorChain(err error) map[string]interface{} {
+	var causes []string
+	root := err
+	for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+		causes = append(causes, next.Error())
+		root = next
+	}
+	if causes == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"error":      err.Error(),
+		"errors":     causes,
+		"cause_type": fmt.Sprintf("%T", root),
+	}
+}
+
+// VerifyLoggly posts a test event with the global logger, see Log.VerifyLoggly.
+func VerifyLoggly() error {
+	return logger.VerifyLoggly()
+}
+
+// VerifyLoggly posts a single test event to loggly and returns an error
+// when the loggly logger is not set, the environment is not one of the
+// loggly environments or loggly rejects the post, e.g. a 403 for a bad
+// token. It makes one attempt within the loggly timeout, for a readiness
+// check at boot.
+func (l *Log) VerifyLoggly() error {
+	l.mu.RLock()
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	env := l.Env
+	envOK := l.logglyEnvs[env]
+	caller := l.callerName(1)
+	l.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("loggly logger not set")
+	}
+	if !envOK {
+		return fmt.Errorf("env %q is not a loggly environment", env)
+	}
+
+	buf, err := encodePost(l, levelOf(INFO).char, env, caller, []interface{}{"plywood loggly check"})
+	if err != nil {
+		return err
+	}
+	defer putBuffer(buf)
+	s.m.Lock()
+	client, timeout, headers := s.Client, s.timeout, s.headers
+	s.m.Unlock()
+	if _, err := postOnce(context.Background(), client, s.url, buf.Bytes(), timeout, headers, false); err != nil {
+		return fmt.Errorf("loggly check failed: %v", err)
+	}
+	return nil
+}
+
+// SetLogglyToken sets the loggly customer token and (re)creates the loggly logger.
+func SetLogglyToken(token string) {
+	logger.SetLogglyToken(token)
+}
+
+// SetLogglyToken sets the loggly customer token and (re)creates the loggly logger.
+func (l *Log) SetLogglyToken(token string) {
+	l.mu.Lock()
+	l.logglyToken = token
+	l.mu.Unlock()
+	if err := l.SetLogger("loggly"); err != nil {
+		diag("E " + err.Error() + "] \n")
+	}
+}
+
+// SetLogglyEnvironments sets the environments whose events are posted to loggly.
+func SetLogglyEnvironments(envs ...string) {
+	logger.SetLogglyEnvironments(envs...)
+}
+
+// SetLogglyEnvironments sets the environments whose events are posted to
+// loggly, production and staging by default. Events of other environments
+// are written to stderr instead.
+func (l *Log) SetLogglyEnvironments(envs ...string) {
+	m := make(map[string]bool, len(envs))
+	for _, env := range envs {
+		m[env] = true
+	}
+	l.mu.Lock()
+	l.logglyEnvs = m
+	l.mu.Unlock()
+}
+
+// SetLogglyHost overrides the loggly host, e.g. for EU or custom deployments.
+func SetLogglyHost(host string) {
+	logger.SetLogglyHost(host)
+}
+
+// SetLogglyHost overrides the loggly host, e.g. for EU or custom deployments.
+func (l *Log) SetLogglyHost(host string) {
+	l.mu.Lock()
+	l.logglyHost = host
+	token := l.logglyToken
+	l.mu.Unlock()
+	if token != "" {
+		l.SetLogger("loggly")
+	}
+}
+
+// SetLogglyTags adds tags to every loggly event of the global logger, see Log.SetLogglyTags.
+func SetLogglyTags(tags ...string) {
+	logger.SetLogglyTags(tags...)
+}
+
+// SetLogglyTags adds tags to every loggly event, after the app name in the
+// tag segment of the url, e.g. /tag/app,eu-west,web. An event adds its own
+// with a _tags key in its map message, e.g. {"_tags": "billing"}.
+func (l *Log) SetLogglyTags(tags ...string) {
+	l.mu.Lock()
+	l.logglyTags = append([]string(nil), tags...)
+	token := l.logglyToken
+	l.mu.Unlock()
+	if token != "" {
+		l.SetLogger("loggly")
+	}
+}
+
+// SetLogglyBatchSize batches loggly events into bulk posts of n events, n <= 1 disables batching.
+func SetLogglyBatchSize(n int) {
+	logger.SetLogglyBatchSize(n)
+}
+
+// SetLogglyBatchSize batches loggly events into bulk posts of n events, n <= 1 disables batching.
+// Reducing the size does not flush the events batched so far.
+func (l *Log) SetLogglyBatchSize(n int) {
+	l.mu.Lock()
+	l.logglyBatchSize = n
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.batchSize = n
+		s.m.Unlock()
+	}
+}
+
+// SetLogglyFlushInterval posts the batched loggly events every d, zero disables the ticker.
+func SetLogglyFlushInterval(d time.Duration) {
+	logger.SetLogglyFlushInterval(d)
+}
+
+// SetLogglyFlushInterval posts the batched loggly events every d, zero disables the ticker.
+func (l *Log) SetLogglyFlushInterval(d time.Duration) {
+	l.mu.Lock()
+	l.logglyFlushInterval = d
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.setFlushInterval(d)
+	}
+}
+
+// SetLogglyRetries retries failed loggly posts up to n times.
+func SetLogglyRetries(n int) {
+	logger.SetLogglyRetries(n)
+}
+
+// SetLogglyRetries retries failed loggly posts up to n times.
+func (l *Log) SetLogglyRetries(n int) {
+	l.mu.Lock()
+	l.logglyRetries = n
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.retries = n
+		s.m.Unlock()
+	}
+}
+
+// SetLogglyRetryDelay sets the delay before the first retry, it doubles on every retry.
+func SetLogglyRetryDelay(d time.Duration) {
+	logger.SetLogglyRetryDelay(d)
+}
+
+// SetLogglyRetryDelay sets the delay before the first retry, it doubles on every retry.
+func (l *Log) SetLogglyRetryDelay(d time.Duration) {
+	l.mu.Lock()
+	l.logglyRetryDelay = d
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.delay = d
+		s.m.Unlock()
+	}
+}
+
+// SetLogglyTimeout bounds the time of a single loggly post, zero disables it.
+func SetLogglyTimeout(d time.Duration) {
+	logger.SetLogglyTimeout(d)
+}
+
+// SetLogglyTimeout bounds the time of a single loggly post, zero disables it.
+// The timeout applies to clients set with SetLogglyClient too.
+func (l *Log) SetLogglyTimeout(d time.Duration) {
+	l.mu.Lock()
+	l.logglyTimeout = d
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.timeout = d
+		s.m.Unlock()
+	}
+}
+
+// SetLogglySendTimeout bounds the synchronous loggly sends of the global logger, see Log.SetLogglySendTimeout.
+func SetLogglySendTimeout(d time.Duration) {
+	logger.SetLogglySendTimeout(d)
+}
+
+// SetLogglySendTimeout bounds the time a logging call waits on a synchronous
+// loggly send, retries included, unlike SetLogglyTimeout which bounds each
+// post. The context of a Ctx logging call bounds it too. Zero disables it.
+func (l *Log) SetLogglySendTimeout(d time.Duration) {
+	l.mu.Lock()
+	l.logglySendTimeout = d
+	l.mu.Unlock()
+}
+
+// SetLogglyGzip gzip compresses loggly posts of at least 1KB.
+func SetLogglyGzip(on bool) {
+	logger.SetLogglyGzip(on)
+}
+
+// SetLogglyGzip gzip compresses loggly posts of at least 1KB, smaller
+// ones are not worth it. Bulk posts benefit the most.
+func (l *Log) SetLogglyGzip(on bool) {
+	l.mu.Lock()
+	l.logglyGzip = on
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.gzip = on
+		s.m.Unlock()
+	}
+}
+
+// SetLogglyLevelFormat sets how the level of loggly posts is represented, see Log.SetLogglyLevelFormat.
+func SetLogglyLevelFormat(format string) error {
+	return logger.SetLogglyLevelFormat(format)
+}
+
+// SetLogglyLevelFormat sets how the level of loggly and webhook posts is
+// represented, LevelFormatChar by default. LevelFormatName and
+// LevelFormatNum make it easier to filter on in dashboards.
+func (l *Log) SetLogglyLevelFormat(format string) error {
+	switch format {
+	case LevelFormatChar, LevelFormatName, LevelFormatNum:
+		l.mu.Lock()
+		l.logglyLevelFormat = format
+		l.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("unknown level format %q", format)
+}
+
+// SetLogglyClient sets the http client of the global logger's loggly posts, see Log.SetLogglyClient.
+func SetLogglyClient(c *http.Client) {
+	logger.SetLogglyClient(c)
+}
+
+// SetLogglyClient sets the http client of loggly posts, for proxies, mTLS
+// or instrumented transports. It replaces the client of the loggly logger
+// and is kept for the loggers created later by SetLogglyToken or
+// SetLogglyHost. nil returns to a client of the loggly settings.
+func (l *Log) SetLogglyClient(c *http.Client) {
+	l.mu.Lock()
+	l.logglyClient = c
+	if c == nil {
+		c = newClient(l)
+	}
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.Client = c
+		s.m.Unlock()
+	}
+}
+
+// withTransport returns a copy of c using the transport t.
+func withTransport(c *http.Client, t *http.Transport) *http.Client {
+	cc := *c
+	cc.Transport = nil // a nil *http.Transport is not a nil RoundTripper
+	if t != nil {
+		cc.Transport = t
+	}
+	return &cc
+}
+
+// SetLogglyTransport sets the http transport of the global logger's loggly posts, see Log.SetLogglyTransport.
+func SetLogglyTransport(t *http.Transport) {
+	logger.SetLogglyTransport(t)
+}
+
+// SetLogglyTransport sets the http transport of loggly posts, for
+// connection pooling and keep alive tuning. It replaces the transport of a
+// client set with SetLogglyClient too. nil returns to http.DefaultTransport.
+func (l *Log) SetLogglyTransport(t *http.Transport) {
+	l.mu.Lock()
+	l.logglyTransport = t
+	if l.logglyClient != nil {
+		l.logglyClient = withTransport(l.logglyClient, t)
+	}
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	l.mu.Unlock()
+	if ok {
+		s.m.Lock()
+		s.Client = withTransport(s.Client, t)
+		s.m.Unlock()
+	}
+}
+
+// SetLogglyMaxIdleConns keeps up to n idle connections of the global logger's loggly posts, see Log.SetLogglyMaxIdleConns.
+func SetLogglyMaxIdleConns(n int) {
+	logger.SetLogglyMaxIdleConns(n)
+}
+
+// SetLogglyMaxIdleConns keeps up to n idle connections to the loggly host
+// open for reuse. The default transport keeps 2, which makes a busy async
+// logger dial again for most posts past the second worker.
+func (l *Log) SetLogglyMaxIdleConns(n int) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = n
+	t.MaxIdleConnsPerHost = n
+	l.SetLogglyTransport(t)
+}
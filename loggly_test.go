@@ -0,0 +1,758 @@
+package plywood
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logglyRecorder is a fake loggly endpoint recording every posted body.
+type logglyRecorder struct {
+	*httptest.Server
+	m      sync.Mutex
+	bodies [][]byte
+	paths  []string
+}
+
+func newLogglyRecorder() *logglyRecorder {
+	r := &logglyRecorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		r.m.Lock()
+		r.bodies = append(r.bodies, b)
+		r.paths = append(r.paths, req.URL.Path)
+		r.m.Unlock()
+	}))
+	return r
+}
+
+// posts returns the recorded bodies decoded as loggly posts.
+func (r *logglyRecorder) posts(t *testing.T) []LogglyPost {
+	r.m.Lock()
+	defer r.m.Unlock()
+	posts := make([]LogglyPost, len(r.bodies))
+	for i, b := range r.bodies {
+		if err := json.Unmarshal(b, &posts[i]); err != nil {
+			t.Fatalf("%s: %s", err, b)
+		}
+	}
+	return posts
+}
+
+// useRecorder points the loggly logger of l at r.
+func useRecorder(l *Log, r *logglyRecorder) {
+	l.SetLogglyToken("testtoken")
+	s := l.Loggers["loggly"].(*Loggly)
+	s.url = r.URL
+	s.bulkUrl = r.URL + "/bulk"
+	l.toLoggly = true
+}
+
+// requests returns the recorded bodies and paths.
+func (r *logglyRecorder) requests() ([][]byte, []string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return append([][]byte(nil), r.bodies...), append([]string(nil), r.paths...)
+}
+
+func TestLogglyBulk(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.SetLogglyBatchSize(3)
+	useRecorder(l, r)
+
+	l.Info("one")
+	l.Info("two")
+	if bodies, _ := r.requests(); len(bodies) != 0 {
+		t.Fatalf("expected no posts before the batch is full got %d", len(bodies))
+	}
+	l.Info("three")
+	l.Info("four")
+
+	bodies, paths := r.requests()
+	if len(bodies) != 1 || paths[0] != "/bulk" {
+		t.Fatalf("expected 1 bulk post got %d %v", len(bodies), paths)
+	}
+	lines := strings.Split(string(bodies[0]), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 events got %q", bodies[0])
+	}
+	for i, str := range []string{"one", "two", "three"} {
+		var p LogglyPost
+		if err := json.Unmarshal([]byte(lines[i]), &p); err != nil {
+			t.Fatal(err)
+		}
+		if msg := p.Msg.(map[string]interface{}); msg["str"] != str {
+			t.Errorf("expected %s got %v", str, msg)
+		}
+	}
+
+	// the remaining event is posted on close
+	l.Close()
+	if bodies, _ = r.requests(); len(bodies) != 2 || !strings.Contains(string(bodies[1]), "four") {
+		t.Errorf("expected remaining event flushed on close got %q", bodies)
+	}
+}
+
+func TestSetLogglyFlushUnlocked(t *testing.T) {
+	posting, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(posting)
+		<-release
+	}))
+	defer srv.Close()
+	l := New("test", "production", INFO)
+	l.SetLogglyBatchSize(10)
+	l.SetLogglyToken("testtoken")
+	s := l.Loggers["loggly"].(*Loggly)
+	s.url, s.bulkUrl = srv.URL, srv.URL+"/bulk"
+	l.toLoggly = true
+	l.Info("batched")
+
+	done := make(chan error)
+	go func() { done <- l.SetLogger("loggly") }()
+	<-posting
+	got := make(chan uint)
+	go func() { got <- l.Level() }()
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Error("Level blocked while the old batch posts")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLogglyFlushInterval(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.SetLogglyBatchSize(100)
+	useRecorder(l, r)
+	l.SetLogglyFlushInterval(5 * time.Millisecond)
+	defer l.Close()
+
+	l.Info("one")
+	l.Info("two")
+	for i := 0; i < 200; i++ {
+		if bodies, _ := r.requests(); len(bodies) > 0 {
+			if n := strings.Count(string(bodies[0]), "\n") + 1; n != 2 {
+				t.Errorf("expected 2 events got %d", n)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("batch not flushed by the ticker")
+}
+
+func TestLogglyBulkEnvironment(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "testing", INFO)
+	l.SetLogglyBatchSize(2)
+	useRecorder(l, r)
+	l.Info("one")
+	l.Info("two")
+	l.Close()
+	if bodies, _ := r.requests(); len(bodies) != 0 {
+		t.Errorf("expected no posts outside loggly environments got %d", len(bodies))
+	}
+}
+
+// flakyServer fails the first failures requests with status.
+type flakyServer struct {
+	*httptest.Server
+	m        sync.Mutex
+	attempts int
+}
+
+func newFlakyServer(failures, status int) *flakyServer {
+	f := &flakyServer{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		f.m.Lock()
+		f.attempts++
+		n := f.attempts
+		f.m.Unlock()
+		if n <= failures {
+			w.WriteHeader(status)
+		}
+	}))
+	return f
+}
+
+func TestLogglyRetry(t *testing.T) {
+	tests := []struct {
+		status   int
+		failures int
+		retries  int
+		attempts int
+		ok       bool
+	}{
+		{http.StatusServiceUnavailable, 2, 3, 3, true},
+		{http.StatusTooManyRequests, 1, 3, 2, true},
+		{http.StatusInternalServerError, 5, 2, 3, false},
+		{http.StatusBadRequest, 1, 3, 1, false},
+	}
+	for _, tt := range tests {
+		f := newFlakyServer(tt.failures, tt.status)
+		l := New("test", "production", INFO)
+		l.SetLogglyToken("testtoken")
+		l.SetLogglyRetries(tt.retries)
+		l.SetLogglyRetryDelay(time.Millisecond)
+		s := l.Loggers["loggly"].(*Loggly)
+		s.url = f.URL
+
+		err := s.Send("E", "production", "retry")
+		if (err == nil) != tt.ok {
+			t.Errorf("%d: unexpected error %v", tt.status, err)
+		}
+		if f.attempts != tt.attempts {
+			t.Errorf("%d: expected %d attempts got %d", tt.status, tt.attempts, f.attempts)
+		}
+		f.Close()
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		min := 10 * time.Millisecond << uint(attempt)
+		if d := backoff(10*time.Millisecond, attempt); d < min || d > min+min/2 {
+			t.Errorf("%d: backoff %s out of range", attempt, d)
+		}
+	}
+}
+
+func TestLogglyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	for _, custom := range []bool{false, true} {
+		l := New("test", "production", INFO)
+		l.SetLogglyToken("testtoken")
+		l.SetLogglyTimeout(20 * time.Millisecond)
+		if custom {
+			l.SetLogglyClient(&http.Client{})
+		}
+		s := l.Loggers["loggly"].(*Loggly)
+		s.url = srv.URL
+
+		start := time.Now()
+		if err := s.Send("E", "production", "slow"); err == nil {
+			t.Errorf("custom %t: expected timeout error", custom)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("custom %t: send took %s", custom, elapsed)
+		}
+	}
+}
+
+func TestLogglyPostNoBleed(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	l.Info(map[string]interface{}{"user": "a", "id": 1})
+	l.Info("second")
+	l.Info(42)
+	l.WithFields(map[string]interface{}{"req": "r1"}).Info("fourth")
+	l.Info("fifth")
+
+	var msgs []map[string]interface{}
+	bodies, _ := r.requests()
+	for _, b := range bodies {
+		var p struct {
+			Msg map[string]interface{} `json:"msg"`
+		}
+		if err := json.Unmarshal(b, &p); err != nil {
+			t.Fatalf("%s: %s", err, b)
+		}
+		msgs = append(msgs, p.Msg)
+	}
+	expected := []map[string]interface{}{
+		{"user": "a", "id": 1.0},
+		{"str": "second"},
+		{"int": 42.0},
+		{"req": "r1", "str": "fourth"},
+		{"str": "fifth"},
+	}
+	if len(msgs) != len(expected) {
+		t.Fatalf("expected %d posts got %d", len(expected), len(msgs))
+	}
+	for i, m := range expected {
+		if len(msgs[i]) != len(m) {
+			t.Errorf("%d: expected %v got %v", i, m, msgs[i])
+			continue
+		}
+		for k, v := range m {
+			if msgs[i][k] != v {
+				t.Errorf("%d: expected %v got %v", i, m, msgs[i])
+			}
+		}
+	}
+}
+
+func BenchmarkEncodePost(b *testing.B) {
+	l := New("test", "production", INFO)
+	data := []interface{}{"event"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, err := encodePost(l, "I", "production", "caller", data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		putBuffer(buf)
+	}
+}
+
+// BenchmarkMarshalPost is the unpooled encoding encodePost replaced.
+func BenchmarkMarshalPost(b *testing.B) {
+	l := New("test", "production", INFO)
+	data := []interface{}{"event"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := &LogglyPost{
+			Timestamp: iso8601(timeNow().UTC()),
+			Env:       "production",
+			App:       l.App,
+			Host:      l.Host,
+			Caller:    "caller",
+			Pid:       pid,
+			Level:     "I",
+			Msg:       logglyMsg(data),
+		}
+		if _, err := json.Marshal(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLogglyEnvironments(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "qa", INFO)
+	useRecorder(l, r)
+
+	l.Info("dropped")
+	if bodies, _ := r.requests(); len(bodies) != 0 {
+		t.Fatalf("expected qa rejected by default got %d posts", len(bodies))
+	}
+	l.SetLogglyEnvironments("qa")
+	l.Info("accepted")
+	l.SetEnv("production")
+	l.Info("rejected")
+
+	posts := r.posts(t)
+	if len(posts) != 1 || posts[0].Env != "qa" {
+		t.Errorf("expected only the qa event posted got %+v", posts)
+	}
+	if other := New("test", "qa", INFO); other.logglyEnvs["qa"] {
+		t.Error("expected the environments set per logger")
+	}
+}
+
+func TestLogglyGzip(t *testing.T) {
+	var m sync.Mutex
+	var encodings []string
+	var posts []LogglyPost
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := req.Body
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(req.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		var p LogglyPost
+		if err := json.NewDecoder(body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		m.Lock()
+		encodings = append(encodings, req.Header.Get("Content-Encoding"))
+		posts = append(posts, p)
+		m.Unlock()
+	}))
+	defer srv.Close()
+	l := New("test", "production", INFO)
+	l.SetLogglyGzip(true)
+	l.SetLogglyToken("testtoken")
+	l.Loggers["loggly"].(*Loggly).url = srv.URL
+	l.EnableLoggly(true)
+
+	big := strings.Repeat("x", 2*gzipMinSize)
+	l.Info("tiny")
+	l.Info(big)
+
+	m.Lock()
+	defer m.Unlock()
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts got %d", len(posts))
+	}
+	if encodings[0] != "" || encodings[1] != "gzip" {
+		t.Errorf("expected only the large post compressed got %q", encodings)
+	}
+	if msg, _ := posts[1].Msg.(map[string]interface{}); msg["str"] != big {
+		t.Error("expected the compressed post to round trip")
+	}
+}
+
+func TestLogglyLevelFormat(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{LevelFormatChar, `"level":"W"`},
+		{LevelFormatName, `"level":"warning"`},
+		{LevelFormatNum, `"level":2`},
+	}
+	for i, tt := range tests {
+		if err := l.SetLogglyLevelFormat(tt.format); err != nil {
+			t.Fatal(err)
+		}
+		l.Warning("levels")
+		bodies, _ := r.requests()
+		if len(bodies) != i+1 || !strings.Contains(string(bodies[i]), tt.expected) {
+			t.Errorf("%s: expected %s in %s", tt.format, tt.expected, bodies)
+		}
+	}
+	if err := l.SetLogglyLevelFormat("emoji"); err == nil {
+		t.Error("expected an unknown format rejected")
+	}
+}
+
+func TestLogglyMsgTypes(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	l.Error(errors.New("disk full"))
+	l.Info(true)
+	l.Info(nil)
+	l.Info(1500 * time.Microsecond)
+	l.Info(time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.FixedZone("CET", 3600)))
+	l.Info(int8(5))
+	l.Info(uint16(9))
+	l.Info(int16(-3))
+	l.Info(uintptr(7))
+
+	bodies, _ := r.requests()
+	if len(bodies) != 9 {
+		t.Fatalf("expected 9 posts got %d", len(bodies))
+	}
+	for i, want := range []string{
+		`"msg":{"error":"disk full"}`,
+		`"msg":{"bool":true}`,
+		`"msg":{"null":null}`,
+		`"msg":{"duration":"1.5ms","ms":1.5}`,
+		`"msg":{"time":"2024-01-02T02:04:05.006Z"}`,
+		`"msg":{"int":5}`,
+		`"msg":{"int":9}`,
+		`"msg":{"int":-3}`,
+		`"msg":{"int":7}`,
+	} {
+		if !strings.Contains(string(bodies[i]), want) {
+			t.Errorf("expected %s in %s", want, bodies[i])
+		}
+	}
+}
+
+func TestLogglySlices(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	l.Info([]int{1, 2, 3})
+	l.Info([]map[string]interface{}{{"id": 1}, {"id": 2}})
+	l.Info([2]string{"a", "b"})
+	l.WithFields(map[string]interface{}{"request_id": "abc"}).Info(map[string]string{"k": "v"})
+
+	bodies, _ := r.requests()
+	if len(bodies) != 4 {
+		t.Fatalf("expected 4 posts got %d", len(bodies))
+	}
+	for i, want := range []string{
+		`"msg":{"array":[1,2,3]}`,
+		`"msg":{"array":[{"id":1},{"id":2}]}`,
+		`"msg":{"array":["a","b"]}`,
+		`"msg":{"k":"v","request_id":"abc"}`,
+	} {
+		if !strings.Contains(string(bodies[i]), want) {
+			t.Errorf("expected %s in %s", want, bodies[i])
+		}
+	}
+}
+
+// codeError is an error type for the root of a wrapped chain.
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestLogglyWrappedError(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+
+	root := &codeError{7}
+	err := fmt.Errorf("load config: %w", fmt.Errorf("read: %w", root))
+	l.Error(err)
+
+	posts := r.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post got %d", len(posts))
+	}
+	msg := posts[0].Msg.(map[string]interface{})
+	if msg["error"] != err.Error() {
+		t.Errorf("expected the full message got %v", msg["error"])
+	}
+	causes, _ := msg["errors"].([]interface{})
+	if len(causes) != 2 || causes[0] != "read: code 7" || causes[1] != "code 7" {
+		t.Errorf("unexpected chain %v", msg["errors"])
+	}
+	if msg["cause_type"] != "*plywood.codeError" {
+		t.Errorf("expected the root cause type got %v", msg["cause_type"])
+	}
+}
+
+func TestVerifyLoggly(t *testing.T) {
+	status := http.StatusOK
+	var posts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	l := New("test", "production", INFO)
+	if err := l.VerifyLoggly(); err == nil {
+		t.Error("expected an error without a loggly logger")
+	}
+	l.SetLogglyToken("testtoken")
+	l.Loggers["loggly"].(*Loggly).url = ts.URL
+
+	if err := l.VerifyLoggly(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	status = http.StatusForbidden
+	if err := l.VerifyLoggly(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a 403 error got %v", err)
+	}
+	if posts != 2 {
+		t.Errorf("expected one post per check got %d", posts)
+	}
+
+	l.SetEnv("development")
+	if err := l.VerifyLoggly(); err == nil {
+		t.Error("expected an error outside the loggly environments")
+	}
+}
+
+func TestLogglySendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+	l := New("test", "production", INFO)
+	l.SetLogglyToken("testtoken")
+	s := l.Loggers["loggly"].(*Loggly)
+	s.url = ts.URL
+	l.toLoggly = true
+	l.SetLogglySendTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	err := l.Info("slow")
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("expected the call bounded by the send timeout, took %s", took)
+	}
+	if err == nil {
+		t.Error("expected a deadline error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := s.SendCtx(ctx, "I", "production", "direct"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error got %v", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("expected SendCtx bounded by ctx, took %s", took)
+	}
+}
+
+func TestLogglyMaxIdleConns(t *testing.T) {
+	// dials counts the connections to the server over two rounds of
+	// concurrent posts.
+	dials := func(setup func(l *Log)) int {
+		var m sync.Mutex
+		n := 0
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}))
+		srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				m.Lock()
+				n++
+				m.Unlock()
+			}
+		}
+		srv.Start()
+		defer srv.Close()
+
+		l := New("test", "production", INFO)
+		setup(l)
+		l.SetLogglyToken("testtoken")
+		s := l.Loggers["loggly"].(*Loggly)
+		s.url = srv.URL
+		for round := 0; round < 2; round++ {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := s.Send("I", "production", "busy"); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			// let the transport return the connections to its idle pool
+			time.Sleep(50 * time.Millisecond)
+		}
+		if tr, ok := s.Client.Transport.(*http.Transport); ok {
+			tr.CloseIdleConnections()
+		}
+		m.Lock()
+		defer m.Unlock()
+		return n
+	}
+
+	defaults := dials(func(l *Log) { l.SetLogglyTransport(&http.Transport{}) })
+	tuned := dials(func(l *Log) { l.SetLogglyMaxIdleConns(8) })
+	if tuned > 8 {
+		t.Errorf("expected the tuned transport to reuse its 8 connections, dialed %d", tuned)
+	}
+	if defaults <= tuned {
+		t.Errorf("expected the 2 idle connection default to dial more than %d, dialed %d", tuned, defaults)
+	}
+}
+
+func TestLogglyTags(t *testing.T) {
+	var m sync.Mutex
+	var paths, tags []string
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		m.Lock()
+		paths = append(paths, req.URL.Path)
+		tags = append(tags, req.Header.Get("X-LOGGLY-TAG"))
+		bodies = append(bodies, string(b))
+		m.Unlock()
+	}))
+	defer srv.Close()
+
+	l := New("app", "production", INFO)
+	l.SetLogglyToken("testtoken")
+	l.SetLogglyTags("eu-west", "web")
+	s := l.Loggers["loggly"].(*Loggly)
+	if want := "https://" + logglyHost + "/inputs/testtoken/tag/app,eu-west,web"; s.url != want {
+		t.Errorf("expected url %s got %s", want, s.url)
+	}
+	if want := "https://" + logglyHost + "/bulk/testtoken/tag/app,eu-west,web"; s.bulkUrl != want {
+		t.Errorf("expected bulk url %s got %s", want, s.bulkUrl)
+	}
+
+	s.url = srv.URL + "/inputs/testtoken/tag/app,eu-west,web"
+	l.toLoggly = true
+	event := map[string]interface{}{"user": "ann", "_tags": []string{"billing", "audit"}}
+	l.Info(event)
+	l.Info("untagged")
+
+	m.Lock()
+	defer m.Unlock()
+	if len(tags) != 2 || tags[0] != "billing,audit" || tags[1] != "" {
+		t.Errorf("unexpected tag headers %q", tags)
+	}
+	if strings.Contains(bodies[0], "_tags") || !strings.Contains(bodies[0], `"user":"ann"`) {
+		t.Errorf("expected the tags removed from the message %s", bodies[0])
+	}
+	if _, ok := event["_tags"]; !ok {
+		t.Error("the map of the caller was changed")
+	}
+	if paths[0] != "/inputs/testtoken/tag/app,eu-west,web" {
+		t.Errorf("unexpected path %s", paths[0])
+	}
+}
+
+// recordTransport records the urls of its requests and answers 200.
+type recordTransport struct {
+	m    sync.Mutex
+	urls []string
+}
+
+func (rt *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.m.Lock()
+	rt.urls = append(rt.urls, req.URL.String())
+	rt.m.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestSetLogglyClient(t *testing.T) {
+	rt := &recordTransport{}
+	l := New("app", "production", INFO)
+	l.SetLogglyClient(&http.Client{Transport: rt})
+	l.SetLogglyToken("testtoken")
+	l.toLoggly = true
+	l.Info("first")
+
+	l.SetLogglyHost("logs-eu.example.com")
+	l.Info("second")
+
+	rt.m.Lock()
+	defer rt.m.Unlock()
+	want := []string{
+		"https://" + logglyHost + "/inputs/testtoken/tag/app",
+		"https://logs-eu.example.com/inputs/testtoken/tag/app",
+	}
+	if !reflect.DeepEqual(rt.urls, want) {
+		t.Errorf("expected requests %q got %q", want, rt.urls)
+	}
+}
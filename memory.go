@@ -0,0 +1,67 @@
+package plywood
+
+import (
+	"sync"
+)
+
+// MemoryEvent is a log event captured by a MemorySender.
+type MemoryEvent struct {
+	Severity string      // severity level character
+	Env      string      // environment
+	Data     interface{} // the message as posted to loggly, fields included
+}
+
+// MemorySender implements sender and keeps every event in memory, for
+// asserting what was logged in tests.
+type MemorySender struct {
+	m      *sync.Mutex
+	events []MemoryEvent
+}
+
+// NewMemorySender creates an empty MemorySender.
+func NewMemorySender() *MemorySender {
+	return &MemorySender{m: &sync.Mutex{}}
+}
+
+// Send records a log event.
+func (s *MemorySender) Send(severity, env string, data interface{}) error {
+	s.m.Lock()
+	s.events = append(s.events, MemoryEvent{Severity: severity, Env: env, Data: logglyMsg(data)})
+	s.m.Unlock()
+	return nil
+}
+
+// Events returns a copy of the events recorded so far.
+func (s *MemorySender) Events() []MemoryEvent {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]MemoryEvent(nil), s.events...)
+}
+
+// Reset forgets the recorded events.
+func (s *MemorySender) Reset() {
+	s.m.Lock()
+	s.events = nil
+	s.m.Unlock()
+}
+
+// Capture turns on the memory logger of the global logger, see Log.Capture.
+func Capture() *MemorySender {
+	return logger.Capture()
+}
+
+// Capture turns on the memory logger, created with SetLogger("memory")
+// when missing, and returns it.
+func (l *Log) Capture() *MemorySender {
+	l.mu.RLock()
+	_, ok := l.Loggers["memory"].(*MemorySender)
+	l.mu.RUnlock()
+	if !ok {
+		l.SetLogger("memory")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.toMemory = true
+	l.toDiscard = false
+	return l.Loggers["memory"].(*MemorySender)
+}
@@ -0,0 +1,35 @@
+package plywood
+
+import (
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	l := New("test", "staging", INFO)
+	mem := l.Capture()
+
+	l.Info(map[string]interface{}{"user": "ann", "id": 7})
+	l.Warningf("retry %d of %d", 1, 3)
+	l.Debug("hidden")
+
+	events := mem.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+	m, ok := events[0].Data.(map[string]interface{})
+	if events[0].Severity != "I" || events[0].Env != "staging" || !ok || m["user"] != "ann" || m["id"] != 7 {
+		t.Errorf("unexpected map event %+v", events[0])
+	}
+	m, ok = events[1].Data.(map[string]interface{})
+	if events[1].Severity != "W" || !ok || m["str"] != "retry 1 of 3" {
+		t.Errorf("unexpected formatted event %+v", events[1])
+	}
+
+	mem.Reset()
+	if n := len(mem.Events()); n != 0 {
+		t.Errorf("expected no events after Reset got %d", n)
+	}
+	if l.Capture() != mem {
+		t.Error("expected Capture to return the existing memory logger")
+	}
+}
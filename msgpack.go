@@ -0,0 +1,229 @@
+package plywood
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Msgpack implements sender and writes every event as its LogglyPost
+// encoded as a msgpack map, after the length of the map as a 4 byte big
+// endian integer.
+type Msgpack struct {
+	w   io.Writer
+	m   *sync.Mutex
+	log *Log // owning log, source of the post fields and clock
+}
+
+// msgpackPool holds the buffers events are encoded into.
+var msgpackPool = sync.Pool{New: func() interface{} { b := make([]byte, 0, 512); return &b }}
+
+// Send a log event to the msgpack sink.
+func (s *Msgpack) Send(severity, env string, data interface{}) error {
+	s.log.mu.RLock()
+	caller := s.log.callerName(1)
+	s.log.mu.RUnlock()
+	return s.sendCaller(severity, env, caller, data)
+}
+
+// sendCaller writes a log event logged by caller as a single write.
+func (s *Msgpack) sendCaller(severity, env, caller string, data interface{}) error {
+	p := newPost(s.log, severity, env, caller, data)
+	bp := msgpackPool.Get().(*[]byte)
+	b := appendPost(append((*bp)[:0], 0, 0, 0, 0), p)
+	putPost(p)
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+
+	s.m.Lock()
+	_, err := s.w.Write(b)
+	s.m.Unlock()
+	*bp = b
+	msgpackPool.Put(bp)
+	return err
+}
+
+// appendPost appends p as a msgpack map with the json keys of LogglyPost.
+func appendPost(b []byte, p *LogglyPost) []byte {
+	b = append(b, 0x88) // fixmap of 8
+	b = appendMsgpackString(appendMsgpackString(b, "timestamp"), p.Timestamp)
+	b = appendMsgpackString(appendMsgpackString(b, "env"), p.Env)
+	b = appendMsgpackString(appendMsgpackString(b, "app"), p.App)
+	b = appendMsgpackString(appendMsgpackString(b, "caller"), p.Caller)
+	b = appendMsgpackString(appendMsgpackString(b, "host"), p.Host)
+	b = appendMsgpackInt(appendMsgpackString(b, "pid"), int64(p.Pid))
+	b = appendMsgpack(appendMsgpackString(b, "level"), p.Level)
+	return appendMsgpack(appendMsgpackString(b, "msg"), p.Msg)
+}
+
+// appendMsgpack appends v encoded as msgpack. Types without a msgpack
+// counterpart, like structs, are encoded as their json form would be and
+// unmarshalable ones as text.
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case string:
+		return appendMsgpackString(b, v)
+	case []byte:
+		return appendMsgpackBinary(b, v)
+	case int:
+		return appendMsgpackInt(b, int64(v))
+	case int8:
+		return appendMsgpackInt(b, int64(v))
+	case int16:
+		return appendMsgpackInt(b, int64(v))
+	case int32:
+		return appendMsgpackInt(b, int64(v))
+	case int64:
+		return appendMsgpackInt(b, v)
+	case uint:
+		return appendMsgpackUint(b, uint64(v))
+	case uint8:
+		return appendMsgpackUint(b, uint64(v))
+	case uint16:
+		return appendMsgpackUint(b, uint64(v))
+	case uint32:
+		return appendMsgpackUint(b, uint64(v))
+	case uint64:
+		return appendMsgpackUint(b, v)
+	case uintptr:
+		return appendMsgpackUint(b, uint64(v))
+	case float32:
+		b = append(b, 0xca)
+		return binary.BigEndian.AppendUint32(b, math.Float32bits(v))
+	case float64:
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v))
+	case time.Time:
+		return appendMsgpackString(b, iso8601(v.UTC()))
+	case error:
+		return appendMsgpackString(b, v.Error())
+	case map[string]interface{}:
+		b = appendMsgpackHeader(b, 0x80, 0xde, len(v))
+		for k, e := range v {
+			b = appendMsgpack(appendMsgpackString(b, k), e)
+		}
+		return b
+	case []interface{}:
+		b = appendMsgpackHeader(b, 0x90, 0xdc, len(v))
+		for _, e := range v {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case []string:
+		b = appendMsgpackHeader(b, 0x90, 0xdc, len(v))
+		for _, e := range v {
+			b = appendMsgpackString(b, e)
+		}
+		return b
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return append(b, 0xc0)
+	}
+	j, err := json.Marshal(v)
+	if err != nil {
+		return appendMsgpackString(b, fmt.Sprint(v))
+	}
+	var generic interface{}
+	if err := json.Unmarshal(j, &generic); err != nil {
+		return appendMsgpackString(b, fmt.Sprint(v))
+	}
+	return appendMsgpack(b, generic)
+}
+
+// appendMsgpackHeader appends the header of a map or array of n entries,
+// fix is its fix type and wide the 16 bit one, followed by the 32 bit one.
+func appendMsgpackHeader(b []byte, fix, wide byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, wide), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, wide+1), uint32(n))
+}
+
+// appendMsgpackString appends s as a msgpack str.
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgpackBinary appends p as a msgpack bin.
+func appendMsgpackBinary(b []byte, p []byte) []byte {
+	switch n := len(p); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, p...)
+}
+
+// appendMsgpackInt appends n in the smallest msgpack int type holding it.
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendMsgpackUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n)) // negative fixint
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+}
+
+// appendMsgpackUint appends n in the smallest msgpack uint type holding it.
+func appendMsgpackUint(b []byte, n uint64) []byte {
+	switch {
+	case n <= 0x7f:
+		return append(b, byte(n)) // positive fixint
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+}
+
+// SetMsgpackSink adds the msgpack logger to the global logger, see Log.SetMsgpackSink.
+func SetMsgpackSink(w io.Writer) {
+	logger.SetMsgpackSink(w)
+}
+
+// SetMsgpackSink adds a logger named msgpack writing every event to w as a
+// length prefixed msgpack map, with the fields of the loggly json post.
+// It is cheaper to encode than json for binary pipelines. Like the loggers
+// of SetMultiLogger it is on from the start and its level is set with
+// SetLoggerLevel. w gets one Write per event and is not closed by Close.
+func (l *Log) SetMsgpackSink(w io.Writer) {
+	l.setPostLogger("msgpack", &Msgpack{w: w, m: &sync.Mutex{}, log: l})
+}
@@ -0,0 +1,198 @@
+package plywood
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// msgpackDecoder decodes the msgpack subset appendMsgpack writes.
+type msgpackDecoder struct {
+	b []byte
+}
+
+func (d *msgpackDecoder) next(n int) []byte {
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p
+}
+
+func (d *msgpackDecoder) decode() interface{} {
+	c := d.next(1)[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c)
+	case c >= 0xe0:
+		return int64(int8(c))
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return string(d.next(int(c & 0x1f)))
+	}
+	switch c {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xc4:
+		return append([]byte(nil), d.next(int(d.next(1)[0]))...)
+	case 0xca:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(d.next(4))))
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(d.next(8)))
+	case 0xcc:
+		return int64(d.next(1)[0])
+	case 0xcd:
+		return int64(binary.BigEndian.Uint16(d.next(2)))
+	case 0xce:
+		return int64(binary.BigEndian.Uint32(d.next(4)))
+	case 0xcf:
+		return binary.BigEndian.Uint64(d.next(8))
+	case 0xd0:
+		return int64(int8(d.next(1)[0]))
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(d.next(2))))
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(d.next(4))))
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(d.next(8)))
+	case 0xd9:
+		return string(d.next(int(d.next(1)[0])))
+	case 0xda:
+		return string(d.next(int(binary.BigEndian.Uint16(d.next(2)))))
+	case 0xdc:
+		return d.decodeArray(int(binary.BigEndian.Uint16(d.next(2))))
+	case 0xde:
+		return d.decodeMap(int(binary.BigEndian.Uint16(d.next(2))))
+	}
+	panic(fmt.Sprintf("unexpected msgpack type %#x", c))
+}
+
+func (d *msgpackDecoder) decodeMap(n int) map[string]interface{} {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k := d.decode().(string)
+		m[k] = d.decode()
+	}
+	return m
+}
+
+func (d *msgpackDecoder) decodeArray(n int) []interface{} {
+	a := make([]interface{}, n)
+	for i := range a {
+		a[i] = d.decode()
+	}
+	return a
+}
+
+type msgpackPoint struct {
+	X int    `json:"x"`
+	Y string `json:"y"`
+}
+
+func TestMsgpackSink(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "production", INFO)
+	l.SetClock(func() time.Time { return time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC) })
+	l.SetMsgpackSink(&buf)
+
+	long := string(bytes.Repeat([]byte("s"), 300))
+	l.Info(map[string]interface{}{
+		"str":    "hello",
+		"long":   long,
+		"nil":    nil,
+		"yes":    true,
+		"no":     false,
+		"small":  7,
+		"neg":    -3,
+		"int8":   int8(-100),
+		"int16":  int16(-1000),
+		"int32":  int32(-100000),
+		"int64":  int64(math.MinInt64),
+		"uint8":  uint8(200),
+		"uint16": uint16(60000),
+		"uint32": uint32(4000000000),
+		"uint64": uint64(math.MaxUint64),
+		"f32":    float32(1.5),
+		"f64":    2.25,
+		"bytes":  []byte{1, 2, 3},
+		"list":   []interface{}{"a", 1},
+		"tags":   []string{"x", "y"},
+		"nested": map[string]interface{}{"k": "v"},
+		"point":  msgpackPoint{4, "z"},
+		"when":   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	l.Warning("second")
+
+	d := &msgpackDecoder{b: buf.Bytes()}
+	var events []map[string]interface{}
+	for len(d.b) > 0 {
+		n := int(binary.BigEndian.Uint32(d.next(4)))
+		frame := &msgpackDecoder{b: d.next(n)}
+		events = append(events, frame.decode().(map[string]interface{}))
+		if len(frame.b) != 0 {
+			t.Fatalf("%d bytes left in frame", len(frame.b))
+		}
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+
+	ev := events[0]
+	if ev["timestamp"] != "2014-01-02T10:20:30.000Z" || ev["app"] != "test" || ev["env"] != "production" ||
+		ev["host"] != l.Host || ev["pid"] != int64(pid) || ev["level"] != "I" || ev["caller"] == "" {
+		t.Errorf("unexpected post fields %v", ev)
+	}
+	want := map[string]interface{}{
+		"str":    "hello",
+		"long":   long,
+		"nil":    nil,
+		"yes":    true,
+		"no":     false,
+		"small":  int64(7),
+		"neg":    int64(-3),
+		"int8":   int64(-100),
+		"int16":  int64(-1000),
+		"int32":  int64(-100000),
+		"int64":  int64(math.MinInt64),
+		"uint8":  int64(200),
+		"uint16": int64(60000),
+		"uint32": int64(4000000000),
+		"uint64": uint64(math.MaxUint64),
+		"f32":    1.5,
+		"f64":    2.25,
+		"bytes":  []byte{1, 2, 3},
+		"list":   []interface{}{"a", int64(1)},
+		"tags":   []interface{}{"x", "y"},
+		"nested": map[string]interface{}{"k": "v"},
+		"point":  map[string]interface{}{"x": 4.0, "y": "z"},
+		"when":   "2024-01-02T03:04:05.000Z",
+	}
+	if msg := ev["msg"]; !reflect.DeepEqual(msg, want) {
+		t.Errorf("expected %v got %v", want, msg)
+	}
+	if msg := events[1]["msg"]; !reflect.DeepEqual(msg, map[string]interface{}{"str": "second"}) || events[1]["level"] != "W" {
+		t.Errorf("unexpected second event %v", events[1])
+	}
+}
+
+// BenchmarkMsgpackPost encodes the post of BenchmarkEncodePost as msgpack.
+func BenchmarkMsgpackPost(b *testing.B) {
+	l := New("test", "production", INFO)
+	data := []interface{}{"event"}
+	b.ReportAllocs()
+	var out []byte
+	for i := 0; i < b.N; i++ {
+		p := newPost(l, "I", "production", "caller", data)
+		out = appendPost(out[:0], p)
+		putPost(p)
+	}
+}
@@ -0,0 +1,79 @@
+package plywood
+
+import (
+	"io"
+)
+
+// MultiSender implements sender and forwards every event to each of its
+// senders in order.
+type MultiSender []Sender
+
+// Send forwards a log event to every sender, the errors of the failing
+// ones are returned as SendErrors.
+func (m MultiSender) Send(severity, env string, data interface{}) error {
+	var errs SendErrors
+	for _, s := range m {
+		if err := s.Send(severity, env, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// sendCaller forwards a log event logged by caller, for the senders
+// reporting it.
+func (m MultiSender) sendCaller(severity, env, caller string, data interface{}) error {
+	var errs SendErrors
+	for _, s := range m {
+		if err := sendCaller(s, severity, env, caller, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.err()
+}
+
+// Close closes the senders that can be closed, it returns the first error.
+func (m MultiSender) Close() error {
+	var first error
+	for _, s := range m {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// SetMultiLogger adds a logger named name sending to senders on the global logger, see Log.SetMultiLogger.
+func SetMultiLogger(name string, senders ...Sender) {
+	logger.SetMultiLogger(name, senders...)
+}
+
+// SetMultiLogger adds a logger named name sending each event to every one
+// of senders, as a MultiSender. It gets the structured event, as the
+// loggly, webhook and memory loggers do, use AddWriter for lines. The
+// logger is on from the start, its level is set with SetLoggerLevel and
+// SetAsync queues its events. Setting a name again replaces its senders,
+// the replaced ones are left open as they may be set again.
+func (l *Log) SetMultiLogger(name string, senders ...Sender) {
+	if lineLogger(name) || postLogger(name) {
+		diag("E " + name + " is a built in logger] \n")
+		return
+	}
+	l.setPostLogger(name, MultiSender(append([]Sender(nil), senders...)))
+}
+
+// setPostLogger sets the logger named name to s, getting the structured
+// events from the start, and returns the sender it replaced.
+func (l *Log) setPostLogger(name string, s Sender) Sender {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.Loggers[name]
+	l.Loggers[name] = s
+	l.writers = remove(l.writers, name)
+	if !contains(l.multis, name) {
+		l.multis = append(l.multis, name)
+	}
+	return old
+}
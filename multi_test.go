@@ -0,0 +1,44 @@
+package plywood
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMultiSender(t *testing.T) {
+	a, b := NewMemorySender(), NewMemorySender()
+	l := New("test", "testing", INFO)
+	l.SetMultiLogger("both", a, b)
+
+	if err := l.Info("hello"); err != nil {
+		t.Fatal(err)
+	}
+	for i, mem := range []*MemorySender{a, b} {
+		events := mem.Events()
+		if len(events) != 1 {
+			t.Fatalf("sender %d: expected 1 event got %d", i, len(events))
+		}
+		if m, ok := events[0].Data.(map[string]interface{}); !ok || m["str"] != "hello" {
+			t.Errorf("sender %d: unexpected event %+v", i, events[0])
+		}
+	}
+
+	l.SetLoggerLevel("both", ERROR)
+	l.Info("filtered")
+	if n := len(a.Events()); n != 1 {
+		t.Errorf("expected the level of the multi logger applied got %d events", n)
+	}
+
+	l.SetMultiLogger("both", failSender{errors.New("one")}, a, failSender{errors.New("two")})
+	err := l.Error("boom")
+	errs, ok := err.(SendErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected the multi logger error got %#v", err)
+	}
+	if inner, ok := errs[0].(SendErrors); !ok || inner.Error() != "one; two" {
+		t.Errorf("expected both sender errors got %v", errs[0])
+	}
+	if n := len(a.Events()); n != 2 {
+		t.Errorf("expected the event sent past a failing sender got %d", n)
+	}
+}
@@ -0,0 +1,110 @@
+package plywood
+
+import (
+	"fmt"
+)
+
+// Option configures a Log created by NewWithOptions.
+type Option func(*Log) error
+
+// NewWithOptions creates a new instance of Log configured by opts, applied in order.
+// By default it is named after the program, logs at INFO and has no loggers.
+// When an option fails the loggers set by the ones before are closed.
+func NewWithOptions(opts ...Option) (*Log, error) {
+	l := newLog()
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	// loggly is created last so its url uses the final app name
+	if l.logglyToken != "" {
+		l.SetLogger("loggly")
+	}
+	return l, nil
+}
+
+// WithApp sets the application name, empty uses the program name.
+func WithApp(appName string) Option {
+	return func(l *Log) error {
+		if appName == "" {
+			appName = program
+		}
+		l.App = appName
+		return nil
+	}
+}
+
+// WithEnv sets the logging environment.
+func WithEnv(env string) Option {
+	return func(l *Log) error {
+		l.Env = env
+		return nil
+	}
+}
+
+// WithLevel sets the logging level.
+func WithLevel(level uint) Option {
+	return func(l *Log) error {
+		l.level = level
+		return nil
+	}
+}
+
+// WithStderr logs to standard error.
+func WithStderr() Option {
+	return func(l *Log) error {
+		l.SetLogger("stderr")
+		l.toStderr = true
+		return nil
+	}
+}
+
+// WithStdout logs to standard out.
+func WithStdout() Option {
+	return func(l *Log) error {
+		l.SetLogger("stdout")
+		l.toStdout = true
+		return nil
+	}
+}
+
+// WithLoggly logs to loggly with the customer token.
+func WithLoggly(token string) Option {
+	return func(l *Log) error {
+		if token == "" {
+			return fmt.Errorf("loggly token not set")
+		}
+		l.logglyToken = token
+		l.toLoggly = true
+		return nil
+	}
+}
+
+// WithPrefix writes prefix before the console and file messages, see SetPrefix.
+func WithPrefix(prefix string) Option {
+	return func(l *Log) error {
+		l.prefix = prefix
+		return nil
+	}
+}
+
+// WithTimeTrackThreshold logs only time track events timed higher.
+func WithTimeTrackThreshold(t float64) Option {
+	return func(l *Log) error {
+		l.timeTrackThreshold = t
+		return nil
+	}
+}
+
+// WithFile logs to the file at path.
+func WithFile(path string) Option {
+	return func(l *Log) error {
+		if err := l.SetFileLogger(path); err != nil {
+			return err
+		}
+		l.toFile = true
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package plywood
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "options.log")
+
+	l, err := NewWithOptions(
+		WithLoggly("abc"),
+		WithApp("myservice"),
+		WithEnv("staging"),
+		WithLevel(WARNING),
+		WithStdout(),
+		WithStderr(),
+		WithTimeTrackThreshold(10),
+		WithFile(path),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.App != "myservice" || l.Env != "staging" || l.level != WARNING || l.timeTrackThreshold != 10 {
+		t.Errorf("unexpected configuration %#v", l)
+	}
+	if !l.toStdout || !l.toStderr || !l.toLoggly || !l.toFile {
+		t.Error("expected stdout, stderr, loggly and file enabled")
+	}
+	for _, name := range []string{"stdout", "stderr", "loggly", "file"} {
+		if _, ok := l.Loggers[name]; !ok {
+			t.Errorf("%s logger not registered", name)
+		}
+	}
+	if url := l.Loggers["loggly"].(*Loggly).url; !strings.HasSuffix(url, "/inputs/abc/tag/myservice") {
+		t.Errorf("unexpected loggly url %s", url)
+	}
+}
+
+func TestNewWithOptionsDefaults(t *testing.T) {
+	l, err := NewWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.App != program || l.level != INFO || len(l.Loggers) != 0 {
+		t.Errorf("unexpected defaults %#v", l)
+	}
+	if l.Loggers == nil || l.loggerLevels == nil {
+		t.Error("maps not initialized")
+	}
+}
+
+func TestNewWithOptionsError(t *testing.T) {
+	if _, err := NewWithOptions(WithFile(filepath.Join(os.DevNull, "nope", "x.log"))); err == nil {
+		t.Error("expected error from WithFile")
+	}
+	if _, err := NewWithOptions(WithLoggly("")); err == nil {
+		t.Error("expected error from WithLoggly without a token")
+	}
+
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var built *Log
+	capture := func(l *Log) error { built = l; return nil }
+	if _, err := NewWithOptions(WithFile(filepath.Join(dir, "x.log")), capture, WithLoggly("")); err == nil {
+		t.Fatal("expected error from WithLoggly without a token")
+	}
+	if f := built.Loggers["file"].(*File); f.f != nil {
+		t.Error("expected the file of WithFile closed on error")
+	}
+}
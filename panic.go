@@ -0,0 +1,80 @@
+package plywood
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Recover logs a panic of the calling goroutine with the global logger, see Log.Recover.
+// usage: defer log.Recover()
+func Recover() {
+	if r := recover(); r != nil {
+		logger.recovered(r)
+	}
+}
+
+// Recover logs a panic at FATAL with the goroutine stack, then panics again
+// when SetRepanic is on or lets the goroutine carry on otherwise.
+// usage: defer log.Recover()
+func (l *Log) Recover() {
+	if r := recover(); r != nil {
+		l.recovered(r)
+	}
+}
+
+// recovered logs the recovered value r, with the function that panicked
+// as the caller, and panics again when asked to.
+func (l *Log) recovered(r interface{}) {
+	if l.Enabled(FATAL) {
+		// up from recovered are Recover and the runtime frames of the panic
+		l.send(context.Background(), FATAL, "", []interface{}{"panic:", fmt.Sprint(r)}, true, l.panicCaller(2))
+	}
+	l.mu.RLock()
+	repanic := l.repanic
+	l.mu.RUnlock()
+	if repanic {
+		panic(r)
+	}
+}
+
+// SetRepanic makes Recover panic again once the panic is logged.
+func SetRepanic(on bool) {
+	logger.SetRepanic(on)
+}
+
+// SetRepanic makes Recover panic again once the panic is logged.
+func (l *Log) SetRepanic(on bool) {
+	l.mu.Lock()
+	l.repanic = on
+	l.mu.Unlock()
+}
+
+func Panic(msg ...interface{}) { logger.panicMsg(msg) }
+
+// Panic logs at FATAL and panics with the message.
+func (l *Log) Panic(msg ...interface{}) { l.panicMsg(msg) }
+
+// panicMsg is called by the Panic functions, it logs msg with the caller
+// of Panic and panics.
+func (l *Log) panicMsg(msg []interface{}) {
+	if l.Enabled(FATAL) {
+		l.mu.RLock()
+		caller := l.callerName(2 + l.callerSkip)
+		l.mu.RUnlock()
+		l.send(context.Background(), FATAL, "", msg, false, caller)
+	}
+	panic(sprintln(msg))
+}
+
+// panicCaller returns the caller at depth as callerName does, skipping
+// the frames of the runtime, so for a recovered panic it is the function
+// that panicked. The frames SetCallerFilter matches are skipped too.
+func (l *Log) panicCaller(depth int) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.callerSkipping(depth+1, func(f runtime.Frame) bool {
+		return strings.HasPrefix(f.Function, "runtime.")
+	})
+}
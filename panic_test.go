@@ -0,0 +1,99 @@
+package plywood
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+
+	func() {
+		defer l.Recover()
+		panic("kaboom")
+	}()
+
+	events := mem.Events()
+	if len(events) != 1 || events[0].Severity != "F" {
+		t.Fatalf("expected 1 fatal event got %+v", events)
+	}
+	m, _ := events[0].Data.(map[string]interface{})
+	if m["str"] != "panic: kaboom" {
+		t.Errorf("unexpected message %v", m["str"])
+	}
+	if s, _ := m["stack"].(string); !strings.Contains(s, "TestRecover") {
+		t.Errorf("expected the stack of the panic got %q", s)
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+	l.SetRepanic(true)
+
+	defer func() {
+		if r := recover(); r != "kaboom" {
+			t.Errorf("expected the panic to propagate got %v", r)
+		}
+		if n := len(mem.Events()); n != 1 {
+			t.Errorf("expected the panic logged first got %d events", n)
+		}
+	}()
+	func() {
+		defer l.Recover()
+		panic("kaboom")
+	}()
+}
+
+func TestPanic(t *testing.T) {
+	l := New("test", "production", INFO)
+	mem := l.Capture()
+
+	defer func() {
+		if r := recover(); r != "bad state 3" {
+			t.Errorf("expected a panic with the message got %v", r)
+		}
+		events := mem.Events()
+		if len(events) != 1 || events[0].Severity != "F" {
+			t.Errorf("expected 1 fatal event got %+v", events)
+		}
+	}()
+	l.Panic("bad state", 3)
+}
+
+// explode panics for TestRecoverCaller.
+func explode() {
+	panic("x")
+}
+
+func TestRecoverCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	func() {
+		defer l.Recover()
+		explode()
+	}()
+	first := strings.SplitN(buf.String(), "\n", 2)[0]
+	if !strings.Contains(first, " panic_test.go:") || !strings.HasSuffix(first, ".explode] panic: x") {
+		t.Errorf("expected the panicking function as the caller got %q", first)
+	}
+
+	buf.Reset()
+	var line int
+	func() {
+		defer func() { recover() }()
+		_, _, line, _ = runtime.Caller(0)
+		l.Panic("bad state")
+	}()
+	if want := fmt.Sprintf(" panic_test.go:%d:", line+1); !strings.Contains(buf.String(), want) {
+		t.Errorf("expected the caller of Panic%s got %q", want, buf.String())
+	}
+}
@@ -1,22 +1,24 @@
 package plywood
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Definitions of available levels: Debug < Info < Warning < Error.
+// Definitions of available levels: Debug < Info < Warning < Error < Fatal.
+// Events below the level of a logger are dropped, so the order matters.
 const (
 	DEBUG uint = iota
 	INFO
@@ -25,36 +27,18 @@ const (
 	FATAL
 )
 
-const (
-	logglyUrl = "https://logs-01.loggly.com/inputs/apikey/"
-)
+// defaultTimeTrackThreshold is the default TimeTrack threshold in milliseconds.
+const defaultTimeTrackThreshold = 50.0
 
 var (
-	program            = filepath.Base(os.Args[0])
-	host               = "unknownhost"
-	userName           = "unknownuser"
-	pid                = os.Getpid()
-	severityChars      = [5]rune{'D', 'I', 'W', 'E', 'F'}
-	timeNow            = time.Now // Stubbed out for testing.
-	logglyEnvironments = map[string]bool{
-		"production": true,
-		"staging":    true,
-	}
+	program  = filepath.Base(os.Args[0])
+	host     = "unknownhost"
+	userName = "unknownuser"
+	pid      = os.Getpid()
+	timeNow  = time.Now // Stubbed out for testing.
+	osExit   = os.Exit  // Stubbed out for testing.
 )
 
-// LogglyPost is the json representation of what to send
-// to loggly.
-type LogglyPost struct {
-	Timestamp string      `json:"timestamp"` // loggly iso8601 timestamp
-	Env       string      `json:"env"`       // environment
-	App       string      `json:"app"`       // application name
-	Caller    string      `json:"caller"`    // the package.function.linenum
-	Host      string      `json:"host"`      // hostname
-	Pid       int         `json:"pid"`       // processid
-	Level     string      `json:"level"`     // severity level character
-	Msg       interface{} `json:"msg"`       // logging event message
-}
-
 // Abstraction of log event sender.
 type Sender interface {
 	Send(string, string, interface{}) error
@@ -62,30 +46,78 @@ type Sender interface {
 
 // Console implements sender and logs events to the console.
 type Console struct {
-	w io.Writer
-	m *sync.Mutex
-}
-
-// Loggly contains the meta for sending log events to loggly.
-// Loggly implements sender.
-type Loggly struct {
-	Client *http.Client
-	url    string
+	w     io.Writer
+	m     *sync.Mutex
+	color bool          // color the header by level
+	buf   *bufio.Writer // buffers w, set by SetConsoleBuffer
+	stop  chan struct{} // stops the flush ticker
 }
 
 // Log contains the set loggers. Log output will be sent to
-// and Log.Loggers defined (loggly, stderr, stdout)
+// and Log.Loggers defined (loggly, stderr, stdout, file)
+// The configuration is guarded by mu, setters take the write lock and
+// the logging path a read lock while it resolves the loggers of an event.
 type Log struct {
-	Host               string
-	App                string
-	Env                string
-	Loggers            map[string]Sender
-	level              uint
-	toStderr           bool
-	toStdout           bool
-	toLoggly           bool
-	toLogglya          bool // async loggly posts
-	timeTrackThreshold float64
+	Host                string
+	App                 string
+	Env                 string
+	Loggers             map[string]Sender
+	mu                  *sync.RWMutex
+	level               uint
+	loggerLevels        map[string]uint   // per logger minimum levels
+	samplers            map[uint]*sampler // set by SetSampling
+	limiters            map[uint]*limiter // set by SetRateLimit
+	dedup               *deduper          // set by SetDedup
+	hooks               []Hook            // set by AddHook
+	metricHook          func(level uint)  // set by SetMetricHook
+	traceExtractor      TraceExtractor    // set by SetTraceExtractor
+	writers             []string          // names of the loggers added by AddWriter
+	multis              []string          // names of the loggers set by SetMultiLogger
+	toStderr            bool
+	toStdout            bool
+	toFile              bool
+	toLoggly            bool
+	toLogglya           bool // async loggly posts
+	toWebhook           bool
+	toWebhooka          bool // async webhook posts
+	toSyslog            bool
+	splitStreams        bool // set by SetSplitStreams
+	toMemory            bool // set by Capture
+	toDiscard           bool // set by Silence, cleared by enabling a logger
+	logglyBlock         bool // block async loggly posts when the queue is full
+	logglyQueue         *asyncQueue
+	asyncQueues         map[string]*asyncQueue // per logger queues set by SetAsync
+	logglyToken         string
+	logglyHost          string
+	logglyTags          []string        // set by SetLogglyTags, follow the app name tag
+	logglyEnvs          map[string]bool // environments posted to loggly
+	logglyBatchSize     int
+	logglyFlushInterval time.Duration
+	logglyRetries       int
+	logglyRetryDelay    time.Duration
+	logglyTimeout       time.Duration
+	logglySendTimeout   time.Duration // bounds each synchronous loggly send, set by SetLogglySendTimeout
+	logglyGzip          bool
+	logglyLevelFormat   string          // set by SetLogglyLevelFormat
+	logglyTransport     *http.Transport // set by SetLogglyTransport, nil uses http.DefaultTransport
+	logglyClient        *http.Client    // set by SetLogglyClient, nil creates one
+	timeTrackThreshold  float64
+	stackOnError        bool                   // set by SetStackOnError
+	repanic             bool                   // set by SetRepanic
+	callerSkip          int                    // extra frames skipped for the caller, set by SetCallerSkip
+	callerFilter        func(file string) bool // files skipped for the caller, set by SetCallerFilter
+	callerFullPath      bool                   // set by SetCallerFullPath
+	noCaller            bool                   // set by SetCaller(false)
+	goroutineID         bool                   // set by SetGoroutineID
+	callerFormat        string                 // set by SetCallerFormat, empty is CallerFile
+	fields              map[string]interface{} // set by WithFields
+	globalFields        map[string]interface{} // set by SetGlobalFields, under fields
+	format              string                 // console and file output format
+	headerFormat        []headerPart           // set by SetHeaderFormat, nil uses header
+	lineSep             string                 // ends every console and file line, set by SetLineSeparator
+	prefix              string                 // set by SetPrefix, before the console and file messages
+	clock               func() time.Time       // set by SetClock, nil uses timeNow
+	warned              *warnOnce              // missing logger diagnostics already written
 }
 
 // global logger created on package initialization.
@@ -113,157 +145,278 @@ func init() {
 	logger = New("", "", INFO)
 	flag.BoolVar(&logger.toStderr, "plytostderr", false, "log to standard error")
 	flag.BoolVar(&logger.toStdout, "plytostdout", false, "log to standard out")
+	flag.BoolVar(&logger.toFile, "plytofile", false, "log to file")
 	flag.BoolVar(&logger.toLoggly, "plytologgly", false, "log to loggly")
 	flag.BoolVar(&logger.toLogglya, "plytologglya", false, "log to loggly async")
+	flag.BoolVar(&logger.logglyBlock, "plylogglyblock", false, "block instead of dropping async loggly posts when the queue is full")
+	flag.Func("plylogglytoken", "set loggly customer token", func(token string) error {
+		logger.SetLogglyToken(token)
+		return nil
+	})
+	flag.Func("plylogglyhost", "set loggly host (default "+logglyHost+")", func(host string) error {
+		logger.SetLogglyHost(host)
+		return nil
+	})
 	flag.StringVar(&logger.Env, "plyenv", "development", "set environment")
-	flag.Float64Var(&logger.timeTrackThreshold, "plytimethresh", 50.0, "set threshold for time track events")
-	flag.UintVar(&logger.level, "plylevel", INFO, "set logging level 0=Debug 1=Info 2=Error 3=Warning 4=Fatal")
+	flag.Float64Var(&logger.timeTrackThreshold, "plytimethresh", defaultTimeTrackThreshold, "set threshold for time track events")
+	flag.Var((*Level)(&logger.level), "plylevel", "set logging level by name or number 0=Debug 1=Info 2=Warning 3=Error 4=Fatal")
+	flag.Func("plylevelname", "set logging level by name debug, info, warning, error or fatal", func(name string) error {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		logger.level = level
+		return nil
+	})
 
 	// create all loggers and set their environments.
+	// loggly is created once its token is set.
 	logger.SetLogger("stderr")
 	logger.SetLogger("stdout")
-	logger.SetLogger("loggly")
+	logger.SetLogger("file")
 }
 
 // iso8601 returns a formatted string in iso8601 format.
 func iso8601(t time.Time) string {
-	return fmt.Sprintf("%d-%02d-%02dT%02d:%02d:%02d.%03dZ",
-		t.Year(),
-		t.Month(),
-		t.Day(),
-		t.Hour(),
-		t.Minute(),
-		t.Second(),
-		t.Nanosecond()/100000)
+	return string(appendISO8601(make([]byte, 0, 24), t))
+}
+
+// appendISO8601 appends t as iso8601 does to b.
+func appendISO8601(b []byte, t time.Time) []byte {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	b = strconv.AppendInt(b, int64(year), 10)
+	b = append(b, '-')
+	b = appendPadded(b, int(month), 2)
+	b = append(b, '-')
+	b = appendPadded(b, day, 2)
+	b = append(b, 'T')
+	b = appendPadded(b, hour, 2)
+	b = append(b, ':')
+	b = appendPadded(b, min, 2)
+	b = append(b, ':')
+	b = appendPadded(b, sec, 2)
+	b = append(b, '.')
+	b = appendPadded(b, t.Nanosecond()/int(time.Millisecond), 3)
+	return append(b, 'Z')
+}
+
+// appendPadded appends the non negative n zero padded to width digits.
+func appendPadded(b []byte, n, width int) []byte {
+	for d := 10; width > 1; width-- {
+		if n < d {
+			b = append(b, '0')
+		}
+		d *= 10
+	}
+	return strconv.AppendInt(b, int64(n), 10)
 }
 
 // New creates a new instance of Log that will log to the provided io.Writer only if the method used
 // for logging is enabled for the provided level. See package documentation for more details and examples.
+// An empty appName uses the program name.
 func New(appName, env string, level uint) *Log {
+	l, _ := NewWithOptions(WithApp(appName), WithEnv(env), WithLevel(level))
+	return l
+}
+
+// newLog creates a Log with the default configuration and no loggers.
+func newLog() *Log {
 	return &Log{
-		Host:    host,
-		App:     program,
-		Env:     env,
-		Loggers: map[string]Sender{},
-		level:   level,
+		Host:               host,
+		App:                program,
+		Loggers:            map[string]Sender{},
+		mu:                 &sync.RWMutex{},
+		loggerLevels:       map[string]uint{},
+		samplers:           map[uint]*sampler{},
+		limiters:           map[uint]*limiter{},
+		asyncQueues:        map[string]*asyncQueue{},
+		level:              INFO,
+		logglyHost:         logglyHost,
+		logglyEnvs:         copyEnvs(logglyEnvironments),
+		logglyRetryDelay:   logglyRetryDelay,
+		logglyTimeout:      logglyTimeout,
+		timeTrackThreshold: defaultTimeTrackThreshold,
+		warned:             &warnOnce{seen: map[string]bool{}},
+		lineSep:            defaultLineSeparator(),
+		logglyQueue:        newAsyncQueue(defaultQueueSize, defaultQueueWorkers),
 	}
 }
 
-// Send a log event to the console.
-func (c *Console) Send(severity, env string, data interface{}) (err error) {
-	c.m.Lock()
-	defer c.m.Unlock()
-	if d, ok := data.(string); ok {
-		_, err = fmt.Fprintf(c.w, d)
+// copyEnvs returns a copy of envs.
+func copyEnvs(envs map[string]bool) map[string]bool {
+	m := make(map[string]bool, len(envs))
+	for env, ok := range envs {
+		m[env] = ok
 	}
-	return
+	return m
 }
 
-// Send a log event to loggly.
-func (l *Loggly) Send(severity, env string, data interface{}) error {
-	p := &LogglyPost{
-		Timestamp: iso8601(timeNow().UTC()),
-		Env:       env,
-		App:       program,
-		Host:      host,
-		Caller:    getCallersName(5),
-		Pid:       pid,
-		Level:     severity,
-	}
-	switch data.(type) {
+// Send a log event to the console. A line is a string, a []byte is
+// written as is, a fmt.Stringer as its String and anything else as
+// fmt.Sprint formats it. A nil event writes nothing.
+func (c *Console) Send(severity, env string, data interface{}) (err error) {
+	var d string
+	switch v := data.(type) {
+	case nil:
+		return nil
 	case string:
-		p.Msg = map[string]interface{}{"str": data}
-	case []interface{}:
-		m, _ := data.([]interface{})
-		if len(m) == 1 {
-			switch m[0].(type) {
-			case string:
-				p.Msg = map[string]interface{}{"str": m[0]}
-			case int, int32, int64, uint, uint8, uint32, uint64:
-				p.Msg = map[string]interface{}{"int": m[0]}
-			case float32:
-				p.Msg = map[string]interface{}{"float": m[0].(float32)}
-			case float64:
-				p.Msg = map[string]interface{}{"float": m[0].(float64)}
-			case map[string]interface{}:
-				p.Msg = m[0]
-			default:
-				p.Msg = map[string]interface{}{"interface": m[0]}
-			}
-		} else {
-			p.Msg = map[string]interface{}{"str": fmt.Sprint(m...)}
+		d = v
+	case []byte:
+		c.m.Lock()
+		defer c.m.Unlock()
+		if c.buf != nil {
+			_, err = c.buf.Write(v)
+			return
 		}
-	case map[string]interface{}:
-		p.Msg = data
+		_, err = c.w.Write(v)
+		return
+	case fmt.Stringer:
+		d = v.String()
+	default:
+		d = fmt.Sprint(v)
 	}
 
-	b, err := json.Marshal(p)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "E "+err.Error()+"] \n")
-		return err
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.color {
+		d = colorize(severity, d)
+	}
+	if c.buf != nil {
+		_, err = c.buf.WriteString(d)
+		return
 	}
+	_, err = io.WriteString(c.w, d)
+	return
+}
 
-	// Only send production and staging events to loggly
-	// If not defined send to stderr
-	if _, ok := logglyEnvironments[env]; !ok {
-		fmt.Fprintf(os.Stderr, "E "+"env not set: "+env+"] "+string(b)+"\n")
-		return nil
+// TimeTrack is a helper to get function times
+// usage: defer log.TimeTrack(time.Now())
+func TimeTrack(start time.Time, name interface{}) time.Duration {
+	elapsed, ev := logger.timeTrack(start, name)
+	if ev != nil {
+		logger.log(INFO, ev)
 	}
+	return elapsed
+}
 
-	req, err := http.NewRequest("POST", l.url, strings.NewReader(string(b)))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "E "+err.Error()+"] "+string(b)+"\n")
-		return err
+// TimeTrack is a helper to get function times, it returns the elapsed
+// time whether it was logged or not.
+// usage: defer log.TimeTrack(time.Now(), "functionName")
+func (l *Log) TimeTrack(start time.Time, name interface{}) time.Duration {
+	elapsed, ev := l.timeTrack(start, name)
+	if ev != nil {
+		l.log(INFO, ev)
 	}
-	resp, err := l.Client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "E "+err.Error()+"] "+string(b)+"\n")
-		return err
+	return elapsed
+}
+
+// timeTrack returns the time elapsed since start and the event to log,
+// nil when it is under the threshold. The event keeps name as a string,
+// the function calling TimeTrack and the threshold in flat fields so
+// they can be queried on their own.
+func (l *Log) timeTrack(start time.Time, name interface{}) (time.Duration, map[string]interface{}) {
+	l.mu.RLock()
+	elapsed := l.now().Sub(start)
+	threshold := l.timeTrackThreshold
+	l.mu.RUnlock()
+	ms := float64(elapsed) / float64(time.Millisecond)
+	if ms <= threshold {
+		return elapsed, nil
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "E "+err.Error()+"] "+string(b)+"\n")
-		return err
+	return elapsed, map[string]interface{}{
+		"timetrack": fmt.Sprint(name),
+		"func":      getCallersName(2, CallerFunc, false),
+		"ms":        ms,
+		"threshold": threshold,
 	}
+}
 
-	if resp.StatusCode != 200 {
-		fmt.Fprintf(os.Stderr, "E "+resp.Status+"] "+string(b)+"\n")
-		return fmt.Errorf("%d %s", resp.StatusCode, body)
+// Close flushes the global logger, see Log.Close.
+func Close() error {
+	return logger.Close()
+}
+
+// Close sends the events queued for async loggly posts and SetAsync, stops the queue
+// workers and closes every logger holding resources (those implementing
+// io.Closer, like the file logger). It returns the first error encountered.
+// Fatal and Fatalf call Close before exiting so queued events are not lost.
+func (l *Log) Close() error {
+	l.flushDedup()
+	l.mu.RLock()
+	queues := []*asyncQueue{l.logglyQueue}
+	for _, q := range l.asyncQueues {
+		queues = append(queues, q)
 	}
+	loggers := make([]Sender, 0, len(l.Loggers))
+	for _, s := range l.Loggers {
+		loggers = append(loggers, s)
+	}
+	l.mu.RUnlock()
 
-	return nil
+	for _, q := range queues {
+		q.close()
+	}
+	var first error
+	for _, s := range loggers {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
 }
 
-// TimeTrack is a helper to get function times
-// usage: defer log.TimeTrack(time.Now())
-func TimeTrack(start time.Time, name interface{}) {
-	logger.TimeTrack(start, name)
+// Flush sends the events queued by the global logger, see Log.Flush.
+func Flush() error {
+	return logger.Flush()
 }
 
-// TimeTrack is a helper to get function times
-// usage: defer log.TimeTrack(time.Now(), "functionName")
-func (l *Log) TimeTrack(start time.Time, name interface{}) {
-	elapsed := time.Since(start)
-	ms := float64(elapsed) / float64(time.Millisecond)
-	if ms > l.timeTrackThreshold {
-		logger.Info(map[string]interface{}{
-			"time": map[string]interface{}{
-				"name": name,
-				"ms":   ms,
-			},
-		})
+// Flush waits until the async queues are sent and writes the buffered
+// output of the loggers, e.g. the console buffer and loggly batches.
+// Unlike Close the queues and flush tickers keep running, so l is still
+// usable. It returns the first error.
+func (l *Log) Flush() error {
+	l.flushDedup()
+	l.mu.RLock()
+	queues := []*asyncQueue{l.logglyQueue}
+	for _, q := range l.asyncQueues {
+		queues = append(queues, q)
+	}
+	loggers := make([]Sender, 0, len(l.Loggers))
+	for _, s := range l.Loggers {
+		loggers = append(loggers, s)
+	}
+	l.mu.RUnlock()
+
+	for _, q := range queues {
+		q.flush()
+	}
+	var first error
+	for _, s := range loggers {
+		if f, ok := s.(flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
 	}
+	return first
+}
+
+// flusher is implemented by the loggers buffering their output.
+type flusher interface {
+	Flush() error
 }
 
 // DebugLogger just prints out the current state of the logger.
 func DebugLogger() {
-	fmt.Fprintf(os.Stderr, "%#v\n", logger)
+	diag(fmt.Sprintf("%#v\n", logger))
 }
 
 // DebugLogger just prints out the current state of the logger.
 func (l *Log) DebugLogger() {
-	fmt.Fprintf(os.Stderr, "%#v", l)
+	diag(fmt.Sprintf("%#v", l))
 }
 
 // SetLevel changes the logging level for the log instance.
@@ -271,9 +424,75 @@ func SetLevel(lvl uint) {
 	logger.SetLevel(lvl)
 }
 
-// SetLevel changes the logging level for the log instance.
+// SetLevel changes the logging level for the log instance. The levels set
+// per logger by SetLoggerLevel are left as they are, see SetLevelAll.
 func (l *Log) SetLevel(lvl uint) {
+	l.mu.Lock()
 	l.level = lvl
+	l.mu.Unlock()
+}
+
+// SetLevelAll changes the logging level of the global logger and every logger, see Log.SetLevelAll.
+func SetLevelAll(lvl uint) {
+	logger.SetLevelAll(lvl)
+}
+
+// SetLevelAll changes the logging level for the log instance and clears
+// the levels set per logger by SetLoggerLevel, so every logger logs at lvl.
+func (l *Log) SetLevelAll(lvl uint) {
+	l.mu.Lock()
+	l.level = lvl
+	l.loggerLevels = map[string]uint{}
+	l.mu.Unlock()
+}
+
+// Level returns the logging level of the log instance. There is no package
+// level function as the name is taken by the Level type.
+func (l *Log) Level() uint {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// SetLoggerLevel sets the minimum level of a single logger.
+func SetLoggerLevel(logType string, level uint) {
+	logger.SetLoggerLevel(logType, level)
+}
+
+// SetLoggerLevel sets the minimum level of a single logger, overriding
+// the level of the log instance for that logger only.
+func (l *Log) SetLoggerLevel(logType string, level uint) {
+	l.mu.Lock()
+	l.loggerLevels[logType] = level
+	l.mu.Unlock()
+}
+
+// loggerLevel returns the minimum level of the named logger.
+// The caller must hold l.mu.
+func (l *Log) loggerLevel(logType string) uint {
+	if level, ok := l.loggerLevels[logType]; ok {
+		return level
+	}
+	return l.level
+}
+
+// Enabled reports whether the global logger logs events at level.
+func Enabled(level uint) bool {
+	return logger.Enabled(level)
+}
+
+// Enabled reports whether any logger accepts events at level,
+// use it to guard building costly messages.
+func (l *Log) Enabled(level uint) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	min := l.level
+	for _, lvl := range l.loggerLevels {
+		if lvl < min {
+			min = lvl
+		}
+	}
+	return min <= level
 }
 
 // SetTimeTrackThreshold logs only events timed higher.
@@ -283,7 +502,9 @@ func SetTimeTrackThreshold(t float64) {
 
 // SetTimeTrackThreshold logs only events timed higher.
 func (l *Log) SetTimeTrackThreshold(t float64) {
+	l.mu.Lock()
 	l.timeTrackThreshold = t
+	l.mu.Unlock()
 }
 
 // SetEnv changes the logging environment.
@@ -293,47 +514,256 @@ func SetEnv(env string) {
 
 // SetEnv changes the logging environment.
 func (l *Log) SetEnv(env string) {
+	l.mu.Lock()
 	l.Env = env
+	l.mu.Unlock()
 }
 
-// SetLogger defines which logger to use.
-func SetLogger(logType string) {
-	logger.SetLogger(logType)
+// SetCallerSkip skips n more frames when reporting the caller of an event,
+// for code logging through its own helpers.
+func SetCallerSkip(n int) {
+	logger.SetCallerSkip(n)
+}
+
+// SetCallerSkip skips n more frames when reporting the caller of an event,
+// for code logging through its own helpers.
+func (l *Log) SetCallerSkip(n int) {
+	l.mu.Lock()
+	l.callerSkip = n
+	l.mu.Unlock()
+}
+
+// SetCallerFilter skips the frames of the files fn matches when reporting the caller of an event.
+func SetCallerFilter(fn func(file string) bool) {
+	logger.SetCallerFilter(fn)
+}
+
+// SetCallerFilter skips the frames of the files fn matches, e.g. those of
+// a package wrapping plywood, when reporting the caller of an event. The
+// first frame past the SetCallerSkip ones it does not match is reported,
+// whatever the depth of the wrappers. nil turns the filter off.
+func (l *Log) SetCallerFilter(fn func(file string) bool) {
+	l.mu.Lock()
+	l.callerFilter = fn
+	l.mu.Unlock()
+}
+
+// SetCallerFullPath reports the full source path of the caller of an event
+// instead of the file name.
+func SetCallerFullPath(on bool) {
+	logger.SetCallerFullPath(on)
+}
+
+// SetCallerFullPath reports the full source path of the caller of an event
+// instead of the file name.
+func (l *Log) SetCallerFullPath(on bool) {
+	l.mu.Lock()
+	l.callerFullPath = on
+	l.mu.Unlock()
+}
+
+// Caller formats.
+const (
+	CallerFile = "file" // handler.go:12:api.Serve, the default
+	CallerFunc = "func" // api.Serve:12
+	CallerFull = "full" // github.com/org/app/api.Serve:12
+)
+
+// SetCallerFormat sets how the callers of the global logger's events are written.
+func SetCallerFormat(format string) error {
+	return logger.SetCallerFormat(format)
+}
+
+// SetCallerFormat sets how the caller of an event is written, CallerFile,
+// CallerFunc or CallerFull. SetCallerFullPath only applies to CallerFile.
+func (l *Log) SetCallerFormat(format string) error {
+	switch format {
+	case CallerFile, CallerFunc, CallerFull:
+		l.mu.Lock()
+		l.callerFormat = format
+		l.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("unknown caller format %q", format)
+}
+
+// SetCaller turns the caller of events on or off, off skips the cost of
+// looking it up and logs "-" instead.
+func SetCaller(on bool) {
+	logger.SetCaller(on)
+}
+
+// SetCaller turns the caller of events on or off, off skips the cost of
+// looking it up and logs "-" instead.
+func (l *Log) SetCaller(on bool) {
+	l.mu.Lock()
+	l.noCaller = !on
+	l.mu.Unlock()
+}
+
+// SetClock sets the time source of l, for timestamps, TimeTrack and rate
+// limits. nil returns to the package clock.
+func (l *Log) SetClock(clock func() time.Time) {
+	l.mu.Lock()
+	l.clock = clock
+	l.mu.Unlock()
+}
+
+// now returns the current time of l.
+// The caller must hold l.mu.
+func (l *Log) now() time.Time {
+	if l.clock != nil {
+		return l.clock()
+	}
+	return timeNow()
 }
 
 // SetLogger defines which logger to use.
-func (l *Log) SetLogger(logType string) {
+func SetLogger(logType string) error {
+	return logger.SetLogger(logType)
+}
+
+// SetLogger creates the named logger: loggly, stderr, stdout, file, memory
+// or discard. It returns an error for other names and for loggly without a
+// token, the webhook and syslog loggers are set by SetWebhook and SetSyslog.
+func (l *Log) SetLogger(logType string) error {
+	old, err := l.setLogger(logType)
+	if old != nil {
+		// flush outside the lock, posting may take a while
+		old.Close()
+	}
+	return err
+}
+
+// setLogger creates the named logger under the lock and returns the
+// loggly or console logger it replaced, for the caller to close once it
+// is released: their batch and buffer are written and tickers stopped.
+func (l *Log) setLogger(logType string) (old io.Closer, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	switch logType {
 	case "loggly":
-		l.Loggers[logType] = &Loggly{
-			Client: &http.Client{},
-			url:    logglyUrl + "tag/" + program,
+		if l.logglyToken == "" {
+			return nil, fmt.Errorf("loggly token not set")
+		}
+		if s, ok := l.Loggers[logType].(*Loggly); ok {
+			old = s
 		}
+		l.Loggers[logType] = newLoggly(l)
 	case "stderr":
+		if c, ok := l.Loggers[logType].(*Console); ok {
+			old = c
+		}
 		l.Loggers[logType] = &Console{
-			w: os.Stderr,
-			m: &sync.Mutex{},
+			w:     os.Stderr,
+			m:     stderrMu,
+			color: colorDefault(isTerminal(os.Stderr)),
 		}
 	case "stdout":
+		if c, ok := l.Loggers[logType].(*Console); ok {
+			old = c
+		}
 		l.Loggers[logType] = &Console{
-			w: os.Stdout,
-			m: &sync.Mutex{},
+			w:     os.Stdout,
+			m:     &sync.Mutex{},
+			color: colorDefault(isTerminal(os.Stdout)),
 		}
 	case "file":
-		// TODO implement
+		if _, ok := l.Loggers[logType]; !ok {
+			l.Loggers[logType] = newFile(defaultFilePath())
+		}
+	case "memory":
+		if _, ok := l.Loggers[logType].(*MemorySender); !ok {
+			l.Loggers[logType] = NewMemorySender()
+		}
+	case "discard":
+		l.Loggers[logType] = Discard{}
+		l.silence()
+	default:
+		return nil, fmt.Errorf("unknown logger %q", logType)
+	}
+	return old, nil
+}
+
+// EnableStderr turns logging to standard error on or off.
+func EnableStderr(on bool) { logger.EnableStderr(on) }
+
+// EnableStdout turns logging to standard out on or off.
+func EnableStdout(on bool) { logger.EnableStdout(on) }
+
+// EnableFile turns logging to the file logger on or off.
+func EnableFile(on bool) { logger.EnableFile(on) }
+
+// EnableLoggly turns synchronous loggly posts on or off.
+func EnableLoggly(on bool) { logger.EnableLoggly(on) }
+
+// EnableLogglyAsync turns async loggly posts on or off.
+func EnableLogglyAsync(on bool) { logger.EnableLogglyAsync(on) }
+
+// EnableStderr turns logging to standard error on or off, creating the
+// stderr logger if needed. For the global logger this is the -plytostderr
+// flag, whichever of the two is set last wins.
+func (l *Log) EnableStderr(on bool) {
+	l.enable("stderr", &l.toStderr, on)
+}
+
+// EnableStdout turns logging to standard out on or off, creating the
+// stdout logger if needed. For the global logger this is the -plytostdout
+// flag, whichever of the two is set last wins.
+func (l *Log) EnableStdout(on bool) {
+	l.enable("stdout", &l.toStdout, on)
+}
+
+// EnableFile turns logging to the file logger on or off, creating it with
+// the default path if needed. For the global logger this is the -plytofile
+// flag, whichever of the two is set last wins.
+func (l *Log) EnableFile(on bool) {
+	l.enable("file", &l.toFile, on)
+}
+
+// EnableLoggly turns synchronous loggly posts on or off. The loggly logger
+// is created by SetLogglyToken. For the global logger this is the
+// -plytologgly flag, whichever of the two is set last wins.
+func (l *Log) EnableLoggly(on bool) {
+	l.enable("loggly", &l.toLoggly, on)
+}
+
+// EnableLogglyAsync turns async loggly posts on or off. The loggly logger
+// is created by SetLogglyToken. For the global logger this is the
+// -plytologglya flag, whichever of the two is set last wins.
+func (l *Log) EnableLogglyAsync(on bool) {
+	l.enable("loggly", &l.toLogglya, on)
+}
+
+// enable sets the destination flag dest to on, creating the named logger
+// first when it is missing and can be created without configuration.
+func (l *Log) enable(logType string, dest *bool, on bool) {
+	l.mu.RLock()
+	_, ok := l.Loggers[logType]
+	l.mu.RUnlock()
+	if on && !ok && logType != "loggly" && logType != "webhook" && logType != "syslog" {
+		l.SetLogger(logType)
+	}
+	l.mu.Lock()
+	*dest = on
+	if on {
+		l.toDiscard = false
 	}
+	l.mu.Unlock()
 }
 
-func Debug(msg ...interface{}) error                   { return logger.Debug(msg...) }
-func Debugf(fmtStr string, msg ...interface{}) error   { return logger.Debugf(fmtStr, msg...) }
-func Info(msg ...interface{}) error                    { return logger.Info(msg...) }
-func Infof(fmtStr string, msg ...interface{}) error    { return logger.Infof(fmtStr, msg...) }
-func Error(msg ...interface{}) error                   { return logger.Error(msg...) }
-func Errorf(fmtStr string, msg ...interface{}) error   { return logger.Errorf(fmtStr, msg...) }
-func Warning(msg ...interface{}) error                 { return logger.Warning(msg...) }
-func Warningf(fmtStr string, msg ...interface{}) error { return logger.Warningf(fmtStr, msg...) }
-func Fatal(msg ...interface{})                         { logger.Fatal(msg...) }
-func Fatalf(fmtStr string, msg ...interface{})         { logger.Fatalf(fmtStr, msg...) }
+// The package level logging functions call the same internals as the Log
+// methods so both are the same number of frames away from send.
+func Debug(msg ...interface{}) error                   { return logger.log(DEBUG, msg...) }
+func Debugf(fmtStr string, msg ...interface{}) error   { return logger.logf(DEBUG, fmtStr, msg...) }
+func Info(msg ...interface{}) error                    { return logger.log(INFO, msg...) }
+func Infof(fmtStr string, msg ...interface{}) error    { return logger.logf(INFO, fmtStr, msg...) }
+func Error(msg ...interface{}) error                   { return logger.log(ERROR, msg...) }
+func Errorf(fmtStr string, msg ...interface{}) error   { return logger.logf(ERROR, fmtStr, msg...) }
+func Warning(msg ...interface{}) error                 { return logger.log(WARNING, msg...) }
+func Warningf(fmtStr string, msg ...interface{}) error { return logger.logf(WARNING, fmtStr, msg...) }
+func Fatal(msg ...interface{})                         { logger.log(FATAL, msg...); logger.exit() }
+func Fatalf(fmtStr string, msg ...interface{})         { logger.logf(FATAL, fmtStr, msg...); logger.exit() }
 
 func (l *Log) Debug(msg ...interface{}) error                 { return l.log(DEBUG, msg...) }
 func (l *Log) Debugf(fmtStr string, msg ...interface{}) error { return l.logf(DEBUG, fmtStr, msg...) }
@@ -347,13 +777,60 @@ func (l *Log) Warningf(fmtStr string, msg ...interface{}) error {
 }
 
 func (l *Log) Fatal(msg ...interface{}) {
-	l.log(ERROR, msg...)
-	os.Exit(1)
+	l.log(FATAL, msg...)
+	l.exit()
 }
 
 func (l *Log) Fatalf(fmtStr string, msg ...interface{}) {
-	l.logf(ERROR, fmtStr, msg...)
-	os.Exit(1)
+	l.logf(FATAL, fmtStr, msg...)
+	l.exit()
+}
+
+// exit closes l and exits the program after a fatal event.
+func (l *Log) exit() {
+	l.Close()
+	osExit(1)
+}
+
+func DebugFunc(fn func() []interface{}) error   { return logger.logFunc(DEBUG, fn) }
+func InfoFunc(fn func() []interface{}) error    { return logger.logFunc(INFO, fn) }
+func WarningFunc(fn func() []interface{}) error { return logger.logFunc(WARNING, fn) }
+func ErrorFunc(fn func() []interface{}) error   { return logger.logFunc(ERROR, fn) }
+
+// DebugFunc logs the message returned by fn, fn is only called when DEBUG is enabled.
+func (l *Log) DebugFunc(fn func() []interface{}) error { return l.logFunc(DEBUG, fn) }
+
+// InfoFunc logs the message returned by fn, fn is only called when INFO is enabled.
+func (l *Log) InfoFunc(fn func() []interface{}) error { return l.logFunc(INFO, fn) }
+
+// WarningFunc logs the message returned by fn, fn is only called when WARNING is enabled.
+func (l *Log) WarningFunc(fn func() []interface{}) error { return l.logFunc(WARNING, fn) }
+
+// ErrorFunc logs the message returned by fn, fn is only called when ERROR is enabled.
+func (l *Log) ErrorFunc(fn func() []interface{}) error { return l.logFunc(ERROR, fn) }
+
+func Debugln(msg ...interface{}) error   { return logger.logln(DEBUG, msg...) }
+func Infoln(msg ...interface{}) error    { return logger.logln(INFO, msg...) }
+func Warningln(msg ...interface{}) error { return logger.logln(WARNING, msg...) }
+func Errorln(msg ...interface{}) error   { return logger.logln(ERROR, msg...) }
+func Fatalln(msg ...interface{})         { logger.logln(FATAL, msg...); logger.exit() }
+
+// Debugln logs msg joined as fmt.Sprintln does, a space between every operand.
+func (l *Log) Debugln(msg ...interface{}) error { return l.logln(DEBUG, msg...) }
+
+// Infoln logs msg joined as fmt.Sprintln does, a space between every operand.
+func (l *Log) Infoln(msg ...interface{}) error { return l.logln(INFO, msg...) }
+
+// Warningln logs msg joined as fmt.Sprintln does, a space between every operand.
+func (l *Log) Warningln(msg ...interface{}) error { return l.logln(WARNING, msg...) }
+
+// Errorln logs msg joined as fmt.Sprintln does, a space between every operand.
+func (l *Log) Errorln(msg ...interface{}) error { return l.logln(ERROR, msg...) }
+
+// Fatalln logs msg joined as fmt.Sprintln does and exits.
+func (l *Log) Fatalln(msg ...interface{}) {
+	l.logln(FATAL, msg...)
+	l.exit()
 }
 
 // header generates a formated log header
@@ -363,96 +840,397 @@ func (l *Log) Fatalf(fmtStr string, msg ...interface{}) {
 //        file             The file name
 //        line             The line number
 //        funciton         The calling function
+//        fields           The fields set by WithFields as key=value
 //        msg              The user-supplied message
-func header(severity string, depth int) string {
-	now := timeNow()
+func header(severity string, now time.Time, caller string, fields map[string]interface{}) string {
+	// append to a pooled buffer rather than format with fmt, the returned
+	// string is the only allocation without fields
+	bp := headerPool.Get().(*[]byte)
+	b := append((*bp)[:0], severity...)
+	b = strconv.AppendInt(b, int64(pid), 10)
+	b = append(b, ' ')
+	b = appendISO8601(b, now)
+	b = append(b, ' ')
+	b = append(b, caller...)
+	b = appendFields(b, fields)
+	b = append(b, "] "...)
+	h := string(b)
+	*bp = b
+	headerPool.Put(bp)
+	return h
+}
 
-	h := fmt.Sprintf("%s%d %s %s] ",
-		severity,
-		pid,
-		iso8601(now),
-		getCallersName(depth),
-	)
+// headerPool holds the buffers header appends to.
+var headerPool = sync.Pool{New: func() interface{} {
+	b := make([]byte, 0, 128)
+	return &b
+}}
 
-	return h
+// callerDepth is the number of frames from send to the logging call of the
+// user, through the internal log function and the exported method or
+// package function.
+const callerDepth = 3
+
+// callerSender is implemented by the senders reporting the caller of an
+// event, which send knows better than the sender.
+type callerSender interface {
+	sendCaller(severity, env, caller string, data interface{}) error
+}
+
+// ctxSender is implemented by the senders whose sends are bounded by a
+// context, see SetLogglySendTimeout.
+type ctxSender interface {
+	sendCallerCtx(ctx context.Context, severity, env, caller string, data interface{}) error
+}
+
+// sendCaller sends an event to s along with its caller when s reports it.
+func sendCaller(s Sender, severity, env, caller string, data interface{}) error {
+	if cs, ok := s.(callerSender); ok {
+		return cs.sendCaller(severity, env, caller, data)
+	}
+	return s.Send(severity, env, data)
+}
+
+// SendErrors holds the errors of the loggers failing to send an event.
+type SendErrors []error
+
+func (e SendErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// err returns e as an error, nil when nothing failed.
+func (e SendErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
 }
 
 // log is called by all the other leveled logging functions.
 func (l *Log) log(level uint, msg ...interface{}) error {
-	if l.level > level {
+	if !l.Enabled(level) {
 		return nil
 	}
-	return l.send(level, "", msg...)
+	return l.send(context.Background(), level, "", msg, false, "")
 }
 
 // logf is called by all the other leveled formatted logging functions.
 func (l *Log) logf(level uint, fmtStr string, msg ...interface{}) error {
-	if l.level > level {
+	if !l.Enabled(level) {
 		return nil
 	}
-	return l.send(level, fmtStr, msg...)
+	return l.send(context.Background(), level, fmtStr, msg, false, "")
 }
 
-// send performs the request to the set loggers.
-func (l *Log) send(level uint, fmtStr string, msg ...interface{}) error {
-	severity := string(severityChars[level])
-	if l.toLogglya {
-		go func(s Sender) {
-			var err error
-			if fmtStr != "" {
-				err = s.Send(severity, l.Env, fmt.Sprintf(fmtStr, msg...))
-			} else {
-				err = s.Send(severity, l.Env, msg)
+// logln is called by the space joining logging functions, the message
+// is a single string without the newline of fmt.Sprintln, the line
+// separator ends the line.
+func (l *Log) logln(level uint, msg ...interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	return l.send(context.Background(), level, "", []interface{}{sprintln(msg)}, false, "")
+}
+
+// logFunc is called by the lazily evaluated logging functions.
+func (l *Log) logFunc(level uint, fn func() []interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	return l.send(context.Background(), level, "", fn(), false, "")
+}
+
+// send filters an event through sampling, rate limits and deduplication
+// and emits what is left. With stack the goroutine stack is added, an
+// empty caller is looked up from the call stack.
+func (l *Log) send(ctx context.Context, level uint, fmtStr string, msg []interface{}, stack bool, caller string) error {
+	l.mu.RLock()
+	if sm := l.samplers[level]; sm != nil && !sm.sample() {
+		l.mu.RUnlock()
+		return nil
+	}
+	if r := l.limiters[level]; r != nil && !r.allow(l.now()) {
+		l.mu.RUnlock()
+		return nil
+	}
+	if caller == "" {
+		caller = l.callerName(callerDepth + l.callerSkip)
+	}
+	d, now, hooks, goid, globals := l.dedup, l.now(), l.hooks, l.goroutineID, l.globalFields
+	metric := l.metricHook
+	l.mu.RUnlock()
+
+	if len(globals) > 0 {
+		l = l.withGlobalFields(globals)
+	}
+	if goid {
+		l = l.WithFields(map[string]interface{}{"goid": goroutineID()})
+	}
+
+	if len(hooks) > 0 {
+		var ok bool
+		if l, fmtStr, msg, ok = l.runHooks(hooks, level, fmtStr, msg); !ok {
+			return nil
+		}
+	}
+	if d != nil {
+		summary, ok := d.check(level, text(fmtStr, msg), caller, now)
+		if summary != nil {
+			if metric != nil {
+				metric(summary.level)
 			}
-			if err != nil {
-				fmt.Fprintf(os.Stderr, err.Error())
+			l.emit(ctx, summary.level, "", []interface{}{summary.text()}, false, summary.caller)
+		}
+		if !ok {
+			return nil
+		}
+	}
+	if metric != nil {
+		metric(level)
+	}
+	return l.emit(ctx, level, fmtStr, msg, stack, caller)
+}
+
+// emit performs the request to the set loggers. Async loggly posts are
+// skipped once ctx is done and bounded by the loggly queue. The errors of
+// the loggers sent to synchronously are returned as SendErrors.
+// The loggers and the output line are resolved under a read lock, the
+// loggers are called once it is released.
+func (l *Log) emit(ctx context.Context, level uint, fmtStr string, msg []interface{}, stack bool, caller string) error {
+	severity := levelOf(level).char
+
+	l.mu.RLock()
+	env := l.Env
+	queue, block, sendTimeout := l.logglyQueue, l.logglyBlock, l.logglySendTimeout
+	var trace string
+	if stack || (l.stackOnError && level >= ERROR) {
+		trace = stackTrace()
+	}
+	// loggly, webhook, syslog, memory and the multi loggers
+	var posts []target
+	var asyncPosts []Sender
+	fallback := false // a logger is on but not set, write to stderr instead
+	for _, name := range [...]string{"loggly", "webhook", "syslog", "memory"} {
+		on, async := l.postDestination(name)
+		if !(on || async) || l.loggerLevel(name) > level {
+			continue
+		}
+		s, ok := l.Loggers[name]
+		if !ok || s == nil {
+			l.warned.warn("E " + name + " logger not set, writing to stderr] \n")
+			fallback = true
+			continue
+		}
+		if on {
+			posts = append(posts, target{s, l.asyncQueues[name]})
+		}
+		if async {
+			asyncPosts = append(asyncPosts, s)
+		}
+	}
+	for _, name := range l.multis {
+		if !l.toDiscard && l.loggerLevel(name) <= level {
+			posts = append(posts, target{l.Loggers[name], l.asyncQueues[name]})
+		}
+	}
+	var lines []target
+	stderr := false // the stderr logger already gets the line
+	for _, name := range [...]string{"stderr", "stdout", "file", "discard"} {
+		if !l.lineDestination(name, level) || l.loggerLevel(name) > level {
+			continue
+		}
+		s, ok := l.Loggers[name]
+		if !ok || s == nil {
+			l.warned.warn("E " + name + " logger not set, writing to stderr] \n")
+			fallback = true
+			continue
+		}
+		if name == "stderr" {
+			stderr = true
+		}
+		lines = append(lines, target{s, l.asyncQueues[name]})
+	}
+	if fallback && !stderr {
+		lines = append(lines, target{stderrFallback, nil})
+	}
+	for _, name := range l.writers {
+		if !l.toDiscard && l.loggerLevel(name) <= level {
+			lines = append(lines, target{l.Loggers[name], l.asyncQueues[name]})
+		}
+	}
+	var line string
+	if len(lines) > 0 {
+		// stderr, stdout, file and the added writers
+		switch l.format {
+		case FormatJSON:
+			line = l.jsonLine(severity, caller, fmtStr, msg, trace)
+		case FormatLogfmt:
+			line = l.logfmtLine(level, caller, fmtStr, msg, trace)
+		default:
+			if l.headerFormat != nil {
+				line = l.textLine(l.formatHeader(l.headerFormat, severity, l.now(), caller, l.prefixed(text(fmtStr, msg))), trace)
+			} else {
+				line = l.textLine(header(severity, l.now(), caller, l.fields)+l.prefixed(text(fmtStr, msg)), trace)
 			}
-		}(l.Loggers["loggly"])
+		}
 	}
-	if l.toLoggly {
-		var err error
+	l.mu.RUnlock()
+
+	var data interface{}
+	if len(posts) > 0 || len(asyncPosts) > 0 {
 		if fmtStr != "" {
-			err = l.Loggers["loggly"].Send(severity, l.Env, fmt.Sprintf(fmtStr, msg...))
+			data = l.withFields(fmt.Sprintf(fmtStr, msg...))
 		} else {
-			err = l.Loggers["loggly"].Send(severity, l.Env, msg)
+			data = l.withFields(msg)
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, err.Error())
+		if trace != "" {
+			data = withStack(data, trace)
 		}
 	}
-	if l.toStderr {
-		var err error
-		h := header(severity, 5)
-		// stderr and stdout
-		if fmtStr == "" {
-			err = l.Loggers["stderr"].Send(severity, l.Env, h+fmt.Sprint(msg...)+"\n")
-		} else {
-			err = l.Loggers["stderr"].Send(severity, l.Env, fmt.Sprintf(h+fmtStr+"\n", msg...))
+	for _, s := range asyncPosts {
+		if ctx.Err() != nil {
+			break
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, err.Error())
+		queue.enqueue(ctx, asyncEvent{s: s, severity: severity, env: env, caller: caller, data: data}, block)
+	}
+	var errs SendErrors
+	postCtx := ctx
+	if sendTimeout > 0 && len(posts) > 0 {
+		var cancel context.CancelFunc
+		postCtx, cancel = context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+	}
+	for _, t := range posts {
+		if err := t.send(postCtx, asyncEvent{s: t.s, severity: severity, env: env, caller: caller, data: data}); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	if l.toStdout {
-		var err error
-		h := header(severity, 5)
-		// stderr and stdout
-		if fmtStr == "" {
-			err = l.Loggers["stdout"].Send(severity, l.Env, h+fmt.Sprint(msg...)+"\n")
-		} else {
-			err = l.Loggers["stdout"].Send(severity, l.Env, fmt.Sprintf(h+fmtStr+"\n", msg...))
+	for _, t := range lines {
+		if err := t.send(ctx, asyncEvent{s: t.s, severity: severity, env: env, caller: caller, data: line}); err != nil {
+			errs = append(errs, err)
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, err.Error())
+	}
+
+	return errs.err()
+}
+
+// stderrMu guards the writes to stderr of the stderr console, the
+// fallback and diag, so concurrent lines are never torn.
+var stderrMu = &sync.Mutex{}
+
+// stderrFallback writes the lines of the loggers turned on but not set.
+var stderrFallback Sender = &Console{w: os.Stderr, m: stderrMu}
+
+// diag writes an internal diagnostic, like a failed send, to stderr.
+func diag(msg string) {
+	stderrMu.Lock()
+	defer stderrMu.Unlock()
+	io.WriteString(os.Stderr, msg)
+}
+
+// warnOnce writes each diagnostic to stderr once.
+type warnOnce struct {
+	m    sync.Mutex
+	seen map[string]bool
+}
+
+// warn writes msg to stderr unless it was written before.
+func (w *warnOnce) warn(msg string) {
+	w.m.Lock()
+	seen := w.seen[msg]
+	w.seen[msg] = true
+	w.m.Unlock()
+	if !seen {
+		diag(msg)
+	}
+}
+
+// destination reports whether logging to the named logger is turned on.
+// The caller must hold l.mu.
+func (l *Log) destination(logType string) bool {
+	switch logType {
+	case "stderr":
+		return l.toStderr
+	case "stdout":
+		return l.toStdout
+	case "file":
+		return l.toFile
+	case "discard":
+		return l.toDiscard
+	}
+	return false
+}
+
+// lineDestination reports whether an event at level is written to the
+// named line logger. Split streams send WARNING and up to stderr and the
+// rest to stdout, whatever their Enable settings.
+// The caller must hold l.mu.
+func (l *Log) lineDestination(logType string, level uint) bool {
+	if l.splitStreams {
+		switch logType {
+		case "stderr":
+			return level >= WARNING
+		case "stdout":
+			return level < WARNING
 		}
 	}
+	return l.destination(logType)
+}
 
-	return nil
+// postDestination reports whether events are sent to the named structured
+// logger synchronously and asynchronously.
+// The caller must hold l.mu.
+func (l *Log) postDestination(logType string) (on, async bool) {
+	switch logType {
+	case "loggly":
+		return l.toLoggly, l.toLogglya
+	case "webhook":
+		return l.toWebhook, l.toWebhooka
+	case "syslog":
+		return l.toSyslog, false
+	case "memory":
+		return l.toMemory, false
+	}
+	return false, false
+}
+
+// callerName returns the caller at depth as getCallersName does, or "-"
+// when caller information is turned off.
+// The caller must hold l.mu.
+func (l *Log) callerName(depth int) string {
+	if l.noCaller {
+		return "-"
+	}
+	if l.callerFilter != nil {
+		filter := l.callerFilter
+		return getFilteredCallersName(depth+1, l.callerFormat, l.callerFullPath, func(f runtime.Frame) bool { return filter(f.File) })
+	}
+	return getCallersName(depth+1, l.callerFormat, l.callerFullPath)
+}
+
+// callerSkipping returns the caller at depth as callerName does, or the
+// first one further up the call stack neither skip nor the SetCallerFilter
+// filter match.
+// The caller must hold l.mu.
+func (l *Log) callerSkipping(depth int, skip func(runtime.Frame) bool) string {
+	if l.noCaller {
+		return "-"
+	}
+	filter := l.callerFilter
+	return getFilteredCallersName(depth+1, l.callerFormat, l.callerFullPath, func(f runtime.Frame) bool {
+		return skip(f) || (filter != nil && filter(f.File))
+	})
 }
 
 // Returns a string identifying a function on the call stack.
 // Use depth=1 for the caller of the function that calls getCallersName, etc.
-func getCallersName(depth int) string {
+// The format is one of the caller formats, with fullPath the file is the
+// path reported by the runtime, otherwise its base name.
+func getCallersName(depth int, format string, fullPath bool) string {
 	pc, file, line, ok := runtime.Caller(depth + 1)
 	if !ok {
 		return "???"
@@ -463,14 +1241,44 @@ func getCallersName(depth int) string {
 		fnname = fn.Name()
 	}
 
-	return fmt.Sprintf("%s:%d:%s", lastComponent(file), line, lastComponent(fnname))
+	return callerString(file, line, fnname, format, fullPath)
+}
+
+// getFilteredCallersName returns the caller at depth as getCallersName
+// does, or the first one further up the call stack skip does not match.
+func getFilteredCallersName(depth int, format string, fullPath bool, skip func(runtime.Frame) bool) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(depth+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 && !skip(frame) {
+			return callerString(frame.File, frame.Line, frame.Function, format, fullPath)
+		}
+		if !more {
+			return "???"
+		}
+	}
+}
+
+// callerString formats a caller as file:line:function, or the function
+// and line for CallerFunc and CallerFull.
+func callerString(file string, line int, fnname, format string, fullPath bool) string {
+	switch format {
+	case CallerFunc:
+		return fmt.Sprintf("%s:%d", lastComponent(fnname), line)
+	case CallerFull:
+		return fmt.Sprintf("%s:%d", fnname, line)
+	}
+	if !fullPath {
+		file = lastComponent(file)
+	}
+	return fmt.Sprintf("%s:%d:%s", file, line, lastComponent(fnname))
 }
 
 // lastComponent
 func lastComponent(path string) string {
-	if index := strings.LastIndex(path, "/"); index >= 0 {
-		path = path[index+1:]
-	} else if index = strings.LastIndex(path, "\\"); index >= 0 {
+	if index := strings.LastIndexAny(path, "/\\"); index >= 0 {
 		path = path[index+1:]
 	}
 	return path
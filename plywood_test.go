@@ -1,10 +1,15 @@
 package plywood
 
 import (
-	//"bytes"
-	//"fmt"
-	//"io"
-	//"os"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,10 +18,8 @@ var lg *Log
 
 func init() {
 	lg = New("test", "testing", INFO)
-	lg.SetLogger("loggly")
+	lg.SetLogglyToken("testtoken")
 	lg.SetLogger("stderr")
-	// to send loggly events to loggly instead of stderr
-	//logglyEnvironments["testing"] = true
 }
 
 func TestIso8601(t *testing.T) {
@@ -24,22 +27,177 @@ func TestIso8601(t *testing.T) {
 	if iso == "" {
 		t.Error("timestamp not returned")
 	}
+
+	tests := []struct {
+		nsec     int
+		expected string
+	}{
+		{0, "2014-01-02T10:20:30.000Z"},
+		{999999, "2014-01-02T10:20:30.000Z"},
+		{1000000, "2014-01-02T10:20:30.001Z"},
+		{12345678, "2014-01-02T10:20:30.012Z"},
+		{999000000, "2014-01-02T10:20:30.999Z"},
+		{999999999, "2014-01-02T10:20:30.999Z"},
+	}
+	for _, tt := range tests {
+		tm := time.Date(2014, 1, 2, 10, 20, 30, tt.nsec, time.UTC)
+		if iso := iso8601(tm); iso != tt.expected {
+			t.Errorf("%d: expected %s got %s", tt.nsec, tt.expected, iso)
+		}
+	}
 }
 
 func TestGetCallersName(t *testing.T) {
-	name := getCallersName(0)
+	name := getCallersName(0, CallerFile, false)
 	if name == "???" {
 		t.Error("caller not returned")
 	}
 }
 
+func TestGetCallersNameFullPath(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	if name := getCallersName(0, CallerFile, false); !strings.HasPrefix(name, "plywood_test.go:") {
+		t.Errorf("expected the file name got %s", name)
+	}
+	if name := getCallersName(0, CallerFile, true); !strings.HasPrefix(name, file+":") {
+		t.Errorf("expected the full path %s got %s", file, name)
+	}
+}
+
+func TestLastComponent(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"handler.go", "handler.go"},
+		{"/home/app/api/handler.go", "handler.go"},
+		{`C:\app\api\handler.go`, "handler.go"},
+		{`C:\app/api\handler.go`, "handler.go"},
+		{"github.com/pkar/plywood.Info", "plywood.Info"},
+	}
+	for _, tt := range tests {
+		if got := lastComponent(tt.path); got != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestSetCallerFullPath(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	_, file, _, _ := runtime.Caller(0)
+
+	l.Info("short")
+	if strings.Contains(stdout.String(), file) || !strings.Contains(stdout.String(), " plywood_test.go:") {
+		t.Errorf("expected the file name got %s", stdout.String())
+	}
+	stdout.Reset()
+	l.SetCallerFullPath(true)
+	l.Info("full")
+	if !strings.Contains(stdout.String(), " "+file+":") {
+		t.Errorf("expected the full path %s got %s", file, stdout.String())
+	}
+}
+
+func TestSetCallerFormat(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	pc, _, _, _ := runtime.Caller(0)
+	fn := runtime.FuncForPC(pc).Name() // import/path.TestSetCallerFormat
+
+	tests := []struct {
+		format string
+		caller func(line int) string
+	}{
+		{CallerFile, func(line int) string { return fmt.Sprintf("plywood_test.go:%d:%s", line, lastComponent(fn)) }},
+		{CallerFunc, func(line int) string { return fmt.Sprintf("%s:%d", lastComponent(fn), line) }},
+		{CallerFull, func(line int) string { return fmt.Sprintf("%s:%d", fn, line) }},
+	}
+	for _, tt := range tests {
+		if err := l.SetCallerFormat(tt.format); err != nil {
+			t.Fatal(err)
+		}
+		stdout.Reset()
+		_, _, line, _ := runtime.Caller(0)
+		l.Info("here")
+		if want := " " + tt.caller(line+1) + "] here\n"; !strings.HasSuffix(stdout.String(), want) {
+			t.Errorf("%s: expected %q in %q", tt.format, want, stdout.String())
+		}
+	}
+	if err := l.SetCallerFormat("short"); err == nil {
+		t.Error("expected an unknown caller format error")
+	}
+}
+
 func TestHeader(t *testing.T) {
-	h := header("I", 0)
+	h := header("I", timeNow(), getCallersName(0, CallerFile, false), nil)
 	if h == "" {
 		t.Error("header not returned")
 	}
 }
 
+// sprintfHeader is the fmt based header the appending one replaced.
+func sprintfHeader(severity string, now time.Time, caller string, fields map[string]interface{}) string {
+	var f string
+	if len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f += " " + k + "=" + fmt.Sprint(fields[k])
+		}
+	}
+	return fmt.Sprintf("%s%d %d-%02d-%02dT%02d:%02d:%02d.%03dZ %s%s] ",
+		severity, pid,
+		now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(),
+		now.Nanosecond()/int(time.Millisecond),
+		caller, f)
+}
+
+func TestHeaderGolden(t *testing.T) {
+	times := []time.Time{
+		time.Date(2014, 1, 2, 3, 4, 5, 6e6, time.UTC),
+		time.Date(2024, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Date(999, 10, 10, 10, 10, 10, 0, time.UTC),
+		time.Date(12345, 1, 1, 0, 0, 0, 50e6, time.UTC),
+	}
+	fields := []map[string]interface{}{
+		nil,
+		{"user": "ann"},
+		{"b": 2, "a": 1.5, "c": []int{1}, "d": nil},
+	}
+	for _, now := range times {
+		for _, f := range fields {
+			want := sprintfHeader("W", now, "main.go:12:main.main", f)
+			if got := header("W", now, "main.go:12:main.main", f); got != want {
+				t.Errorf("expected %q got %q", want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkHeader(b *testing.B) {
+	now := time.Date(2014, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		header("I", now, "main.go:12:main.main", nil)
+	}
+}
+
+func BenchmarkSprintfHeader(b *testing.B) {
+	now := time.Date(2014, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sprintfHeader("I", now, "main.go:12:main.main", nil)
+	}
+}
+
 func TestSendString(t *testing.T) {
 	lg.Error("hello", "bb")
 	lg.Errorf("%s, %s", "hello", "aa")
@@ -76,3 +234,685 @@ func TestSendMapStringInterface(t *testing.T) {
 	// renders as string
 	lg.Errorf("%v", msi)
 }
+
+func TestConsoleSendVerbatim(t *testing.T) {
+	for _, msg := range []string{
+		"progress 50% done\n",
+		"literal %s and %d\n",
+		"trailing %",
+		"%%v %!x(MISSING)",
+	} {
+		var buf bytes.Buffer
+		c := &Console{w: &buf, m: &sync.Mutex{}}
+		if err := c.Send("I", "testing", msg); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != msg {
+			t.Errorf("expected %q got %q", msg, buf.String())
+		}
+	}
+}
+
+func TestSendPercentMessages(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	for _, msg := range []string{"progress 50% done", "literal %s and %d"} {
+		l.Info(msg)
+	}
+	l.Infof("%s", "formatted %d")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := []string{"] progress 50% done", "] literal %s and %d", "] formatted %d"}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines got %q", len(expected), buf.String())
+	}
+	for i, e := range expected {
+		if !strings.HasSuffix(lines[i], e) {
+			t.Errorf("expected %q suffix got %q", e, lines[i])
+		}
+	}
+}
+
+func TestSendSpacing(t *testing.T) {
+	var buf bytes.Buffer
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+	useRecorder(l, r)
+
+	l.Error("user", "logged", "in")
+	if !strings.HasSuffix(buf.String(), "] user logged in\n") {
+		t.Errorf("expected a spaced console message got %q", buf.String())
+	}
+	posts := r.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post got %d", len(posts))
+	}
+	if msg, _ := posts[0].Msg.(map[string]interface{}); msg["str"] != "user logged in" {
+		t.Errorf("expected a spaced loggly message got %v", posts[0].Msg)
+	}
+}
+
+func TestSendln(t *testing.T) {
+	var buf bytes.Buffer
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+	useRecorder(l, r)
+
+	l.Error("a", "b")
+	l.Errorln("a", "b")
+	l.Infoln("user", 7, "logged in")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := []string{"] a b", "] a b", "] user 7 logged in"}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines got %q", len(expected), buf.String())
+	}
+	for i, e := range expected {
+		if !strings.HasSuffix(lines[i], e) {
+			t.Errorf("expected %q suffix got %q", e, lines[i])
+		}
+	}
+	posts := r.posts(t)
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts got %d", len(posts))
+	}
+	for i, e := range []string{"a b", "a b", "user 7 logged in"} {
+		if msg, _ := posts[i].Msg.(map[string]interface{}); msg["str"] != e {
+			t.Errorf("expected %q posted got %v", e, posts[i].Msg)
+		}
+	}
+}
+
+func TestLogglyToken(t *testing.T) {
+	l := New("test", "testing", INFO)
+	l.SetLogger("loggly")
+	if _, ok := l.Loggers["loggly"]; ok {
+		t.Error("loggly registered without a token")
+	}
+
+	l.SetLogglyToken("abc123")
+	s, ok := l.Loggers["loggly"].(*Loggly)
+	if !ok {
+		t.Fatal("loggly not registered")
+	}
+	expected := "https://logs-01.loggly.com/inputs/abc123/tag/test"
+	if s.url != expected {
+		t.Errorf("expected %s got %s", expected, s.url)
+	}
+
+	l.SetLogglyHost("logs-eu.example.com")
+	expected = "https://logs-eu.example.com/inputs/abc123/tag/test"
+	if url := l.Loggers["loggly"].(*Loggly).url; url != expected {
+		t.Errorf("expected %s got %s", expected, url)
+	}
+}
+
+func TestLogglyNotRegistered(t *testing.T) {
+	l := New("test", "testing", INFO)
+	l.toLoggly = true
+	l.toLogglya = true
+	l.Info("no token")
+}
+
+func TestLoggerNotSetFallback(t *testing.T) {
+	var buf bytes.Buffer
+	old := stderrFallback
+	stderrFallback = &Console{w: &buf, m: &sync.Mutex{}}
+	defer func() { stderrFallback = old }()
+
+	l := New("test", "production", INFO)
+	l.toStderr = false
+	l.toLoggly = true
+	l.toStdout = true
+	delete(l.Loggers, "stdout")
+	l.Info("first")
+	l.Info("second")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "] first") || !strings.HasSuffix(lines[1], "] second") {
+		t.Errorf("expected each event once on stderr got %q", buf.String())
+	}
+	if n := len(l.warned.seen); n != 2 {
+		t.Errorf("expected a warning for loggly and stdout got %d", n)
+	}
+}
+
+func TestFatalSeverity(t *testing.T) {
+	var code int
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	l.Fatal("fatal")
+	if code != 1 {
+		t.Errorf("expected exit code 1 got %d", code)
+	}
+	if !strings.HasPrefix(buf.String(), "F") {
+		t.Errorf("expected F severity got %q", buf.String())
+	}
+
+	buf.Reset()
+	l.Fatalf("fatal %d", 2)
+	if !strings.HasPrefix(buf.String(), "F") {
+		t.Errorf("expected F severity got %q", buf.String())
+	}
+
+	buf.Reset()
+	l.Fatalln("fatal", "ln")
+	if !strings.HasPrefix(buf.String(), "F") || !strings.HasSuffix(buf.String(), "] fatal ln\n") {
+		t.Errorf("expected a spaced F event got %q", buf.String())
+	}
+}
+
+func TestNewAppName(t *testing.T) {
+	if l := New("", "testing", INFO); l.App != program {
+		t.Errorf("expected %s got %s", program, l.App)
+	}
+
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("myservice", "production", INFO)
+	useRecorder(l, r)
+	l.Info("hello")
+
+	posts := r.posts(t)
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post got %d", len(posts))
+	}
+	if posts[0].App != "myservice" {
+		t.Errorf("expected myservice got %s", posts[0].App)
+	}
+	l.SetLogglyToken("abc")
+	if url := l.Loggers["loggly"].(*Loggly).url; !strings.HasSuffix(url, "/tag/myservice") {
+		t.Errorf("expected myservice tag got %s", url)
+	}
+}
+
+func TestLogglyIdentity(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	a := New("servicea", "production", INFO)
+	a.Host = "hosta"
+	useRecorder(a, r)
+	b := New("serviceb", "production", INFO)
+	b.Host = "hostb"
+	useRecorder(b, r)
+
+	a.Info("from a")
+	b.Info("from b")
+
+	posts := r.posts(t)
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts got %d", len(posts))
+	}
+	if posts[0].App != "servicea" || posts[0].Host != "hosta" {
+		t.Errorf("unexpected identity %s %s", posts[0].App, posts[0].Host)
+	}
+	if posts[1].App != "serviceb" || posts[1].Host != "hostb" {
+		t.Errorf("unexpected identity %s %s", posts[1].App, posts[1].Host)
+	}
+	if posts[0].Pid != os.Getpid() {
+		t.Errorf("expected pid %d got %d", os.Getpid(), posts[0].Pid)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	fixed := time.Date(2014, 1, 2, 10, 20, 30, 12345678, time.UTC)
+	var stdout bytes.Buffer
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.SetClock(func() time.Time { return fixed })
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	useRecorder(l, r)
+
+	l.Info("tick")
+	want := fmt.Sprintf("I%d 2014-01-02T10:20:30.012Z ", pid)
+	if !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("expected prefix %q got %q", want, stdout.String())
+	}
+	posts := r.posts(t)
+	if len(posts) != 1 || posts[0].Timestamp != "2014-01-02T10:20:30.012Z" {
+		t.Errorf("unexpected loggly timestamp %+v", posts)
+	}
+
+	l.SetFormat(FormatJSON)
+	stdout.Reset()
+	l.Info("tock")
+	if !strings.Contains(stdout.String(), `"timestamp":"2014-01-02T10:20:30.012Z"`) {
+		t.Errorf("unexpected json timestamp %s", stdout.String())
+	}
+}
+
+func TestTimeTrackThreshold(t *testing.T) {
+	start := time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC)
+	now := start.Add(10 * time.Millisecond)
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.SetClock(func() time.Time { return now })
+	l.Loggers["stdout"] = s
+	l.toStdout = true
+
+	l.TimeTrack(start, "x")
+	if n := len(s.events()); n != 0 {
+		t.Errorf("expected nothing under the default threshold got %d", n)
+	}
+	now = start.Add(60 * time.Millisecond)
+	l.TimeTrack(start, "x")
+	if n := len(s.events()); n != 1 {
+		t.Errorf("expected 1 event over the default threshold got %d", n)
+	}
+}
+
+type trackedKey struct{ id int }
+
+func TestTimeTrackFields(t *testing.T) {
+	start := time.Date(2014, 1, 2, 10, 20, 30, 0, time.UTC)
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.SetClock(func() time.Time { return start.Add(75 * time.Millisecond) })
+	l.Loggers["loggly"] = s
+	l.toLoggly = true
+
+	func() {
+		defer l.TimeTrack(start, trackedKey{7})
+	}()
+	events := s.events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(events))
+	}
+	ev := events[0].([]interface{})[0].(map[string]interface{})
+	if ev["timetrack"] != "{7}" {
+		t.Errorf("expected the name as a string got %#v", ev["timetrack"])
+	}
+	if ev["ms"] != 75.0 || ev["threshold"] != defaultTimeTrackThreshold {
+		t.Errorf("unexpected ms %v threshold %v", ev["ms"], ev["threshold"])
+	}
+	if fn, _ := ev["func"].(string); !strings.Contains(fn, ".TestTimeTrackFields.") {
+		t.Errorf("expected the deferring function got %q", fn)
+	}
+	if _, ok := ev["time"]; ok {
+		t.Error("expected no nested time key")
+	}
+}
+
+func TestTimeTrackDuration(t *testing.T) {
+	l := New("test", "production", INFO)
+	l.SetTimeTrackThreshold(time.Hour.Seconds() * 1000)
+	start := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	d := l.TimeTrack(start, "sleep")
+	if d < 20*time.Millisecond || d > time.Second {
+		t.Errorf("expected about 20ms got %s", d)
+	}
+}
+
+// logThrough is a helper of the kind SetCallerSkip is for.
+func logThrough(l *Log, msg string) {
+	l.Info(msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	var stdout bytes.Buffer
+	r := newLogglyRecorder()
+	defer r.Close()
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	useRecorder(l, r)
+	defer func(old *Log) { logger = old }(logger)
+	logger = l
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("method")
+	Info("package")
+	l.SetCallerSkip(1)
+	logThrough(l, "wrapped")
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	posts := r.posts(t)
+	if len(lines) != 3 || len(posts) != 3 {
+		t.Fatalf("expected 3 events got %d lines %d posts", len(lines), len(posts))
+	}
+	for i, want := range []string{
+		fmt.Sprintf("plywood_test.go:%d:", line+1),
+		fmt.Sprintf("plywood_test.go:%d:", line+2),
+		fmt.Sprintf("plywood_test.go:%d:", line+4),
+	} {
+		if !strings.Contains(lines[i], " "+want) || !strings.Contains(lines[i], "TestCallerSkip] ") {
+			t.Errorf("expected caller %s got %s", want, lines[i])
+		}
+		if !strings.HasPrefix(posts[i].Caller, want) {
+			t.Errorf("expected loggly caller %s got %s", want, posts[i].Caller)
+		}
+	}
+}
+
+func TestCallerFilter(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetCallerFilter(func(file string) bool { return strings.HasSuffix(file, "/wrap_test.go") })
+
+	_, _, line, _ := runtime.Caller(0)
+	wrapInfo(l, "one wrapper")
+	wrapDeep(l, "two wrappers")
+	l.Info("direct")
+	l.SetCallerFilter(nil)
+	wrapInfo(l, "unfiltered")
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines got %q", stdout.String())
+	}
+	for i, want := range []string{
+		fmt.Sprintf(" plywood_test.go:%d:", line+1),
+		fmt.Sprintf(" plywood_test.go:%d:", line+2),
+		fmt.Sprintf(" plywood_test.go:%d:", line+3),
+		" wrap_test.go:",
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("expected caller%s got %s", want, lines[i])
+		}
+	}
+}
+
+// closeSender records whether it was closed and fails with err.
+type closeSender struct {
+	closed bool
+	err    error
+}
+
+func (s *closeSender) Send(severity, env string, data interface{}) error { return nil }
+
+func (s *closeSender) Close() error {
+	s.closed = true
+	return s.err
+}
+
+func TestClose(t *testing.T) {
+	l := New("test", "testing", INFO)
+	ok := &closeSender{}
+	failing := &closeSender{err: errors.New("close failed")}
+	l.Loggers["ok"] = ok
+	l.Loggers["failing"] = failing
+
+	if err := l.Close(); err != failing.err {
+		t.Errorf("expected %v got %v", failing.err, err)
+	}
+	if !ok.closed || !failing.closed {
+		t.Error("expected both senders closed")
+	}
+}
+
+func TestFatalCloses(t *testing.T) {
+	osExit = func(int) {}
+	defer func() { osExit = os.Exit }()
+
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["loggly"] = s
+	l.toLogglya = true
+	l.Fatal("bye")
+	if n := len(s.events()); n != 1 {
+		t.Errorf("expected queued event sent before exit got %d", n)
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var stdout bytes.Buffer
+	loggly := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.Loggers["loggly"] = loggly
+	l.toStdout = true
+	l.toLoggly = true
+	l.SetLoggerLevel("stdout", DEBUG)
+	l.SetLoggerLevel("loggly", ERROR)
+
+	l.Debug("debug")
+	if !strings.HasSuffix(stdout.String(), "] debug\n") {
+		t.Errorf("debug not sent to stdout %q", stdout.String())
+	}
+	if n := len(loggly.events()); n != 0 {
+		t.Errorf("debug sent to loggly %d", n)
+	}
+
+	l.Error("error")
+	if n := len(loggly.events()); n != 1 {
+		t.Errorf("expected error sent to loggly got %d", n)
+	}
+
+	// without an override a logger uses the level of the log
+	stdout.Reset()
+	delete(l.loggerLevels, "stdout")
+	l.Debug("debug")
+	if stdout.Len() != 0 {
+		t.Errorf("debug sent to stdout without override %q", stdout.String())
+	}
+}
+
+func TestConcurrentConfig(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStdout = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.Info("concurrent", j)
+				l.WithFields(map[string]interface{}{"j": j}).Debug("maybe")
+				l.TimeTrack(time.Now(), "concurrent")
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.SetLevel(uint(j % 3))
+				l.SetLoggerLevel("stdout", uint(j%2))
+				l.SetEnv("env")
+				l.SetTimeTrackThreshold(float64(j))
+				l.SetFormat([]string{FormatText, FormatJSON, FormatLogfmt}[(i+j)%3])
+				l.SetLogger("stderr")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestEnableStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	l := New("test", "testing", INFO)
+	l.EnableStdout(true)
+	os.Stdout = stdout
+
+	l.Info("x")
+	l.EnableStdout(false)
+	l.Info("y")
+	w.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "] x\n") || strings.Count(string(b), "\n") != 1 {
+		t.Errorf("unexpected stdout %q", b)
+	}
+}
+
+func TestEnableLoggly(t *testing.T) {
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["loggly"] = s
+	l.EnableLoggly(true)
+	l.EnableLogglyAsync(true)
+	l.Info("twice")
+	l.Close()
+	if n := len(s.events()); n != 2 {
+		t.Errorf("expected sync and async events got %d", n)
+	}
+	l.EnableLoggly(false)
+	if l.toLoggly {
+		t.Error("loggly still enabled")
+	}
+}
+
+func TestDebugFunc(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	called := false
+	l.DebugFunc(func() []interface{} {
+		called = true
+		return []interface{}{"expensive"}
+	})
+	if called || buf.Len() != 0 {
+		t.Error("debug func called while DEBUG is disabled")
+	}
+	if l.Enabled(DEBUG) || !l.Enabled(INFO) {
+		t.Error("unexpected Enabled result")
+	}
+
+	l.InfoFunc(func() []interface{} { return []interface{}{"computed", 42} })
+	if !strings.HasSuffix(buf.String(), "] computed 42\n") {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+// expensive stands in for a costly message argument.
+type expensive struct{ n int }
+
+func (e *expensive) String() string { return fmt.Sprintf("expensive %d", e.n) }
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	l := New("test", "testing", INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug("value", fmt.Sprint(&expensive{i}))
+	}
+}
+
+func BenchmarkDebugFuncDisabled(b *testing.B) {
+	l := New("test", "testing", INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.DebugFunc(func() []interface{} {
+			return []interface{}{"value", fmt.Sprint(&expensive{i})}
+		})
+	}
+}
+
+func TestSetCaller(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetCaller(false)
+
+	l.Info("anonymous")
+	if strings.Contains(stdout.String(), "plywood_test.go") || !strings.Contains(stdout.String(), " -] anonymous") {
+		t.Errorf("expected no caller got %s", stdout.String())
+	}
+	stdout.Reset()
+	l.SetCaller(true)
+	l.Info("named")
+	if !strings.Contains(stdout.String(), "plywood_test.go") {
+		t.Errorf("expected the caller got %s", stdout.String())
+	}
+}
+
+func benchmarkCaller(b *testing.B, on bool) {
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = &Console{w: ioutil.Discard, m: &sync.Mutex{}}
+	l.toStdout = true
+	l.SetCaller(on)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("event")
+	}
+}
+
+func BenchmarkCallerEnabled(b *testing.B)  { benchmarkCaller(b, true) }
+func BenchmarkCallerDisabled(b *testing.B) { benchmarkCaller(b, false) }
+
+// failSender fails every send with err.
+type failSender struct {
+	err error
+}
+
+func (s failSender) Send(severity, env string, data interface{}) error {
+	return s.err
+}
+
+func TestSendErrors(t *testing.T) {
+	l := New("test", "testing", INFO)
+	l.Loggers["stdout"] = failSender{errors.New("stdout broken")}
+	l.Loggers["file"] = failSender{errors.New("disk full")}
+	l.toStdout, l.toFile = true, true
+	l.toStderr = false
+
+	err := l.Error("boom")
+	errs, ok := err.(SendErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 send errors got %#v", err)
+	}
+	if err.Error() != "stdout broken; disk full" {
+		t.Errorf("unexpected error %q", err)
+	}
+	if err := l.Debug("filtered"); err != nil {
+		t.Errorf("expected no error for a filtered event got %v", err)
+	}
+
+	l.Loggers["file"] = &recordSender{}
+	if err := l.Infof("%d", 1); err == nil || err.Error() != "stdout broken" {
+		t.Errorf("expected the stdout error got %v", err)
+	}
+	l.toStdout = false
+	if err := l.Info("ok"); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+}
+
+func TestSetLoggerUnknown(t *testing.T) {
+	l := New("test", "testing", INFO)
+	if err := l.SetLogger("stdout"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, ok := l.Loggers["stdout"].(*Console); !ok {
+		t.Error("expected the stdout logger")
+	}
+	if err := l.SetLogger("stdrr"); err == nil || !strings.Contains(err.Error(), `"stdrr"`) {
+		t.Errorf("expected an unknown logger error got %v", err)
+	}
+	if _, ok := l.Loggers["stdrr"]; ok {
+		t.Error("unknown logger registered")
+	}
+	if err := l.SetLogger("loggly"); err == nil {
+		t.Error("expected an error for loggly without a token")
+	}
+}
@@ -0,0 +1,79 @@
+package plywood
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket allowing rate events per second, with bursts
+// of up to rate events.
+type limiter struct {
+	m       sync.Mutex
+	rate    float64
+	tokens  float64
+	last    time.Time
+	dropped uint64
+}
+
+func newLimiter(perSecond int, now time.Time) *limiter {
+	return &limiter{
+		rate:   float64(perSecond),
+		tokens: float64(perSecond),
+		last:   now,
+	}
+}
+
+// allow reports whether an event at now fits in the rate.
+func (r *limiter) allow(now time.Time) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += elapsed.Seconds() * r.rate
+		if r.tokens > r.rate {
+			r.tokens = r.rate
+		}
+		r.last = now
+	}
+	if r.tokens < 1 {
+		r.dropped++
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// SetRateLimit drops events logged at level beyond perSecond events a second,
+// perSecond <= 0 turns the limit off.
+func SetRateLimit(level uint, perSecond int) {
+	logger.SetRateLimit(level, perSecond)
+}
+
+// SetRateLimit drops events logged at level beyond perSecond events a second,
+// perSecond <= 0 turns the limit off. Bursts of up to perSecond events pass.
+func (l *Log) SetRateLimit(level uint, perSecond int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if perSecond <= 0 {
+		delete(l.limiters, level)
+		return
+	}
+	l.limiters[level] = newLimiter(perSecond, l.now())
+}
+
+// RateLimitDropped returns the number of events at level dropped by the rate limit.
+func RateLimitDropped(level uint) uint64 {
+	return logger.RateLimitDropped(level)
+}
+
+// RateLimitDropped returns the number of events at level dropped by the rate limit.
+func (l *Log) RateLimitDropped(level uint) uint64 {
+	l.mu.RLock()
+	r := l.limiters[level]
+	l.mu.RUnlock()
+	if r == nil {
+		return 0
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.dropped
+}
@@ -0,0 +1,61 @@
+package plywood
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = s
+	l.toStdout = true
+	l.SetRateLimit(INFO, 10)
+
+	for i := 0; i < 25; i++ {
+		l.Info("burst")
+	}
+	if n := len(s.events()); n != 10 {
+		t.Errorf("expected a burst of 10 got %d", n)
+	}
+	if n := l.RateLimitDropped(INFO); n != 15 {
+		t.Errorf("expected 15 dropped got %d", n)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		l.Info("refill")
+	}
+	if n := len(s.events()); n != 15 {
+		t.Errorf("expected 5 more after half a second got %d", n)
+	}
+
+	// the bucket never holds more than one second of events
+	now = now.Add(time.Minute)
+	for i := 0; i < 20; i++ {
+		l.Info("capped")
+	}
+	if n := len(s.events()); n != 25 {
+		t.Errorf("expected 10 more after a minute got %d", n)
+	}
+	if n := l.RateLimitDropped(INFO); n != 30 {
+		t.Errorf("expected 30 dropped got %d", n)
+	}
+
+	l.Error("other level")
+	if n := len(s.events()); n != 26 {
+		t.Errorf("expected other levels unlimited got %d", n)
+	}
+}
+
+func TestRateLimitAllocs(t *testing.T) {
+	now := time.Now()
+	r := newLimiter(10, now)
+	if n := testing.AllocsPerRun(100, func() { r.allow(now) }); n != 0 {
+		t.Errorf("expected no allocations got %v", n)
+	}
+}
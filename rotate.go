@@ -0,0 +1,187 @@
+package plywood
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// RotatingFile implements sender and logs events to a file, rolled over
+// to path.1, path.2 and so on once it grows past maxBytes.
+type RotatingFile struct {
+	path       string
+	f          *os.File
+	size       int64
+	maxBytes   int64
+	maxBackups int
+	gzip       bool // compress backups to path.N.gz
+	m          *sync.Mutex
+}
+
+// newRotatingFile creates a rotating file sender for path without opening it.
+func newRotatingFile(path string, maxBytes int64, maxBackups int) *RotatingFile {
+	return &RotatingFile{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+		m:          &sync.Mutex{},
+	}
+}
+
+// open opens the file for appending if it is not open already.
+// The caller must hold r.m.
+func (r *RotatingFile) open() error {
+	if r.f != nil {
+		return nil
+	}
+	fh, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return err
+	}
+	r.f, r.size = fh, info.Size()
+	return nil
+}
+
+// backup returns the path of backup n.
+func (r *RotatingFile) backup(n int) string {
+	p := r.path + "." + strconv.Itoa(n)
+	if r.gzip {
+		p += ".gz"
+	}
+	return p
+}
+
+// rotate closes the file, shifts the backups and moves the file to
+// backup 1, the next open starts a new file.
+// The caller must hold r.m.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	if r.maxBackups < 1 {
+		return os.Remove(r.path)
+	}
+	os.Remove(r.backup(r.maxBackups))
+	for n := r.maxBackups - 1; n >= 1; n-- {
+		if err := os.Rename(r.backup(n), r.backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if !r.gzip {
+		return os.Rename(r.path, r.backup(1))
+	}
+	if err := gzipFile(r.path, r.backup(1)); err != nil {
+		return err
+	}
+	return os.Remove(r.path)
+}
+
+// gzipFile writes src gzip compressed to dst.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(out)
+	if _, err := io.Copy(w, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Send a log event to the file, rotating it first when the event does not fit.
+func (r *RotatingFile) Send(severity, env string, data interface{}) error {
+	d, ok := data.(string)
+	if !ok {
+		return nil
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.size > 0 && r.size+int64(len(d)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return fmt.Errorf("rotate %s: %s", r.path, err)
+		}
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	n, err := io.WriteString(r.f, d)
+	r.size += int64(n)
+	return err
+}
+
+// Close closes the underlying file handle. A later Send reopens it.
+func (r *RotatingFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// SetRotatingFile makes the file logger of the global logger rotate, see Log.SetRotatingFile.
+func SetRotatingFile(path string, maxBytes int64, maxBackups int) error {
+	return logger.SetRotatingFile(path, maxBytes, maxBackups)
+}
+
+// SetRotatingFile opens path as the destination of the file logger. Once
+// it would grow past maxBytes it is renamed to path.1, the older backups
+// shift to path.2 and up, keeping maxBackups of them, and a new file starts.
+// maxBytes must be positive.
+func (l *Log) SetRotatingFile(path string, maxBytes int64, maxBackups int) error {
+	if maxBytes <= 0 {
+		return fmt.Errorf("rotating file max bytes must be positive, got %d", maxBytes)
+	}
+	r := newRotatingFile(path, maxBytes, maxBackups)
+	r.m.Lock()
+	err := r.open()
+	r.m.Unlock()
+	if err != nil {
+		return err
+	}
+	l.setFile(r)
+	return nil
+}
+
+// SetRotatingFileGzip compresses the backups of the global logger's rotating file.
+func SetRotatingFileGzip(on bool) {
+	logger.SetRotatingFileGzip(on)
+}
+
+// SetRotatingFileGzip compresses the backups of the rotating file logger
+// to path.N.gz. Backups rotated before are left as they are.
+func (l *Log) SetRotatingFileGzip(on bool) {
+	l.mu.RLock()
+	r, ok := l.Loggers["file"].(*RotatingFile)
+	l.mu.RUnlock()
+	if ok {
+		r.m.Lock()
+		r.gzip = on
+		r.m.Unlock()
+	}
+}
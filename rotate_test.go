@@ -0,0 +1,104 @@
+package plywood
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	r := newRotatingFile(path, 10, 2)
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if err := r.Send("INFO", "testing", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.Close()
+
+	for name, want := range map[string]string{
+		path:        "dddddd\n",
+		path + ".1": "cccccc\n",
+		path + ".2": "bbbbbb\n",
+	} {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: expected %q got %q", name, want, b)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups, stat .3: %v", err)
+	}
+}
+
+func TestRotatingFileGzip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	l := New("test", "testing", INFO)
+	if err := l.SetRotatingFile(path, 60, 1); err != nil {
+		t.Fatal(err)
+	}
+	l.SetRotatingFileGzip(true)
+	l.toFile = true
+	l.Info("first")
+	l.Info("second")
+	l.Close()
+
+	fh, err := os.Open(path + ".1.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	zr, err := gzip.NewReader(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "] first\n") {
+		t.Errorf("unexpected backup %q", b)
+	}
+	b, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "] second\n") {
+		t.Errorf("unexpected file %q", b)
+	}
+}
+
+func TestRotatingFileMaxBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := New("test", "testing", INFO)
+	for _, max := range []int64{0, -1} {
+		if err := l.SetRotatingFile(filepath.Join(dir, "test.log"), max, 2); err == nil {
+			t.Errorf("expected max bytes %d rejected", max)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test.log")); !os.IsNotExist(err) {
+		t.Errorf("expected no file opened, stat: %v", err)
+	}
+}
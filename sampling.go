@@ -0,0 +1,54 @@
+package plywood
+
+import (
+	"sync/atomic"
+)
+
+// sampler lets one of every n events through and counts the rest.
+type sampler struct {
+	n       uint64
+	seen    uint64 // accessed atomically
+	dropped uint64 // accessed atomically
+}
+
+// sample reports whether the next event should be emitted.
+func (s *sampler) sample() bool {
+	if (atomic.AddUint64(&s.seen, 1)-1)%s.n == 0 {
+		return true
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+// SetSampling emits only 1 of every n events logged at level, n <= 1 turns sampling off.
+func SetSampling(level uint, n int) {
+	logger.SetSampling(level, n)
+}
+
+// SetSampling emits only 1 of every n events logged at level, n <= 1 turns sampling off.
+// The first event is always emitted, and the count starts over on every call.
+func (l *Log) SetSampling(level uint, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 1 {
+		delete(l.samplers, level)
+		return
+	}
+	l.samplers[level] = &sampler{n: uint64(n)}
+}
+
+// SampledDropped returns the number of events at level dropped by sampling.
+func SampledDropped(level uint) uint64 {
+	return logger.SampledDropped(level)
+}
+
+// SampledDropped returns the number of events at level dropped by sampling.
+func (l *Log) SampledDropped(level uint) uint64 {
+	l.mu.RLock()
+	s := l.samplers[level]
+	l.mu.RUnlock()
+	if s == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&s.dropped)
+}
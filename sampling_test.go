@@ -0,0 +1,41 @@
+package plywood
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	s := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = s
+	l.toStdout = true
+	l.SetSampling(INFO, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("chatty")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Error("kept")
+
+	if n := len(s.events()); n != 101 {
+		t.Errorf("expected 100 sampled events and 1 error got %d", n)
+	}
+	if n := l.SampledDropped(INFO); n != 900 {
+		t.Errorf("expected 900 dropped got %d", n)
+	}
+
+	l.SetSampling(INFO, 0)
+	l.Info("all")
+	l.Info("all")
+	if n := len(s.events()); n != 103 {
+		t.Errorf("expected sampling off got %d events", n)
+	}
+}
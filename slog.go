@@ -0,0 +1,108 @@
+//go:build go1.21
+
+package plywood
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is a slog.Handler logging through a Log. Attributes become
+// fields, prefixed with the names of the groups they are in.
+type slogHandler struct {
+	l     *Log
+	group string // prefix of attribute keys, e.g. "request."
+}
+
+// SlogHandler returns a slog.Handler logging through the global logger.
+func SlogHandler() slog.Handler {
+	return logger.SlogHandler()
+}
+
+// SlogHandler returns a slog.Handler logging through l, e.g.
+// slog.New(l.SlogHandler()). Levels below slog.LevelInfo log at DEBUG,
+// below slog.LevelWarn at INFO, below slog.LevelError at WARNING and the
+// rest at ERROR.
+func (l *Log) SlogHandler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// slogLevel maps a slog level to a plywood level.
+func slogLevel(level slog.Level) uint {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARNING
+	}
+	return ERROR
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.Enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.l
+	if r.NumAttrs() > 0 {
+		fields := make(map[string]interface{}, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			addAttr(fields, h.group, a)
+			return true
+		})
+		l = l.WithFields(fields)
+	}
+	caller := "-" // a record without a PC has no caller to look up
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		l.mu.RLock()
+		if l.noCaller {
+			caller = "-"
+		} else {
+			caller = callerString(frame.File, frame.Line, frame.Function, l.callerFormat, l.callerFullPath)
+		}
+		l.mu.RUnlock()
+	}
+	return l.send(ctx, slogLevel(r.Level), "", []interface{}{r.Message}, false, caller)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &slogHandler{l: h.l.WithFields(fields), group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, group: h.group + name + "."}
+}
+
+// addAttr adds a to fields under prefix, groups are flattened into
+// prefix.group.key fields and empty attributes are skipped.
+func addAttr(fields map[string]interface{}, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := prefix
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, group, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
@@ -0,0 +1,85 @@
+//go:build go1.21
+
+package plywood
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	loggly := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["loggly"] = loggly
+	l.toLoggly = true
+
+	log := slog.New(l.SlogHandler()).With("service", "api")
+	log.Debug("hidden")
+	log.WithGroup("req").Info("handled", "id", 7, slog.Group("user", "name", "ann"))
+	log.Error("failed", "err", "boom")
+
+	events := loggly.events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+	m, ok := events[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected event %v", events[0])
+	}
+	for k, v := range map[string]interface{}{
+		"str":           "handled",
+		"service":       "api",
+		"req.id":        int64(7),
+		"req.user.name": "ann",
+	} {
+		if m[k] != v {
+			t.Errorf("expected %s=%v got %v", k, v, m)
+		}
+	}
+	if m, _ := events[1].(map[string]interface{}); m["err"] != "boom" || m["req.id"] != nil {
+		t.Errorf("unexpected event %v", events[1])
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", WARNING)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+	h := l.SlogHandler()
+
+	if h.Enabled(context.Background(), slog.LevelInfo) || !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("expected Enabled to follow the level of the logger")
+	}
+	l.SetLevel(DEBUG)
+	if !h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected Enabled to follow level changes")
+	}
+
+	log := slog.New(h)
+	log.Warn("careful")
+	line := stdout.String()
+	if !strings.HasPrefix(line, "W") || !strings.Contains(line, "slog_test.go:") {
+		t.Errorf("expected a warning from the slog call site got %s", line)
+	}
+}
+
+func TestSlogHandlerNoPC(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "built by hand", 0)
+	if err := l.SlogHandler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(stdout.String(), " -] built by hand\n") {
+		t.Errorf("expected no caller got %q", stdout.String())
+	}
+}
@@ -0,0 +1,57 @@
+package plywood
+
+import (
+	"context"
+	"runtime"
+)
+
+// stackTrace returns the stack of the calling goroutine.
+func stackTrace() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// withStack returns the loggly message for data with the stack trace
+// added under the stack key.
+func withStack(data interface{}, stack string) interface{} {
+	m := map[string]interface{}{}
+	if msg, ok := logglyMsg(data).(map[string]interface{}); ok {
+		for k, v := range msg {
+			m[k] = v
+		}
+	}
+	m["stack"] = stack
+	return m
+}
+
+// SetStackOnError adds the goroutine stack to every Error and Fatal event.
+func SetStackOnError(on bool) {
+	logger.SetStackOnError(on)
+}
+
+// SetStackOnError adds the goroutine stack to every Error and Fatal event.
+func (l *Log) SetStackOnError(on bool) {
+	l.mu.Lock()
+	l.stackOnError = on
+	l.mu.Unlock()
+}
+
+func ErrorStack(msg ...interface{}) error { return logger.logStack(ERROR, msg...) }
+
+// ErrorStack logs at ERROR with the goroutine stack, under the stack key
+// of loggly posts and as extra lines on the console.
+func (l *Log) ErrorStack(msg ...interface{}) error { return l.logStack(ERROR, msg...) }
+
+// logStack is log with the stack of the caller.
+func (l *Log) logStack(level uint, msg ...interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+	return l.send(context.Background(), level, "", msg, true, "")
+}
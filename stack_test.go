@@ -0,0 +1,55 @@
+package plywood
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestErrorStack(t *testing.T) {
+	var stdout bytes.Buffer
+	loggly := &recordSender{}
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.Loggers["loggly"] = loggly
+	l.toStdout = true
+	l.toLoggly = true
+
+	l.ErrorStack("boom")
+	if !strings.Contains(stdout.String(), "TestErrorStack") {
+		t.Errorf("expected the test frame on the console got %s", stdout.String())
+	}
+	events := loggly.events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 loggly event got %d", len(events))
+	}
+	m, ok := events[0].(map[string]interface{})
+	if !ok || m["str"] != "boom" {
+		t.Fatalf("unexpected loggly event %v", events[0])
+	}
+	if s, _ := m["stack"].(string); !strings.Contains(s, "TestErrorStack") {
+		t.Errorf("expected the test frame in the stack field got %q", s)
+	}
+}
+
+func TestStackOnError(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New("test", "production", INFO)
+	l.Loggers["stdout"] = &Console{w: &stdout, m: &sync.Mutex{}}
+	l.toStdout = true
+
+	l.Error("no stack")
+	if strings.Contains(stdout.String(), "goroutine") {
+		t.Errorf("unexpected stack %s", stdout.String())
+	}
+	l.SetStackOnError(true)
+	l.Warning("warned")
+	if strings.Contains(stdout.String(), "goroutine") {
+		t.Errorf("unexpected stack on a warning %s", stdout.String())
+	}
+	l.Error("with stack")
+	if !strings.Contains(stdout.String(), "TestStackOnError") {
+		t.Errorf("expected the test frame got %s", stdout.String())
+	}
+}
@@ -0,0 +1,182 @@
+package plywood
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog message body formats.
+const (
+	SyslogFormatText = "text" // the message as text
+	SyslogFormatCEE  = "cee"  // @cee: followed by the event as json
+)
+
+// syslogUser is the user-level messages facility.
+const syslogUser = 1
+
+// syslogSeverities maps severity characters to syslog severities.
+var syslogSeverities = map[string]int{
+	"D": 7, // debug
+	"I": 6, // informational
+	"W": 4, // warning
+	"E": 3, // error
+	"F": 2, // critical
+}
+
+// Syslog implements sender and writes log events as RFC 5424 syslog
+// messages, one per line.
+type Syslog struct {
+	w      io.Writer
+	m      *sync.Mutex
+	format string
+	log    *Log // owning log, source of the app, host and clock
+}
+
+// Send a log event to syslog.
+func (s *Syslog) Send(severity, env string, data interface{}) error {
+	s.log.mu.RLock()
+	caller := s.log.callerName(1)
+	s.log.mu.RUnlock()
+	return s.sendCaller(severity, env, caller, data)
+}
+
+// sendCaller writes a log event logged by caller to syslog.
+func (s *Syslog) sendCaller(severity, env, caller string, data interface{}) error {
+	s.m.Lock()
+	format := s.format
+	s.m.Unlock()
+
+	var body string
+	if format == SyslogFormatCEE {
+		buf, err := encodePost(s.log, severity, env, caller, data)
+		if err != nil {
+			diag("E " + err.Error() + "] \n")
+			return err
+		}
+		body = "@cee: " + buf.String()
+		putBuffer(buf)
+	} else {
+		body = caller + "] " + syslogText(data)
+	}
+	frame := s.header(severity) + body + "\n"
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	_, err := io.WriteString(s.w, frame)
+	return err
+}
+
+// header returns the RFC 5424 header of an event, up to the message,
+// without structured data.
+func (s *Syslog) header(severity string) string {
+	sev, ok := syslogSeverities[severity]
+	if !ok {
+		sev = 5 // notice
+	}
+	s.log.mu.RLock()
+	now, host, app := s.log.now(), s.log.Host, s.log.App
+	s.log.mu.RUnlock()
+	return fmt.Sprintf("<%d>1 %s %s %s %d - - ",
+		syslogUser*8+sev,
+		now.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		syslogName(host),
+		syslogName(app),
+		pid,
+	)
+}
+
+// syslogName returns a header field, "-" when empty and with spaces
+// replaced as they separate the fields.
+func syslogName(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.Replace(s, " ", "_", -1)
+}
+
+// syslogText returns the message of an event as text, the keys of a map
+// message as key=value pairs.
+func syslogText(data interface{}) string {
+	k, v := logglyValue(data)
+	if m, ok := v.(map[string]interface{}); ok && k == "" {
+		return strings.TrimPrefix(formatFields(m), " ")
+	}
+	return fmt.Sprint(v)
+}
+
+// Close closes the connection to syslog.
+func (s *Syslog) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetSyslog connects the syslog logger of the global logger, see Log.SetSyslog.
+func SetSyslog(network, addr string) error {
+	return logger.SetSyslog(network, addr)
+}
+
+// SetSyslog creates the syslog logger writing to addr over network, "udp",
+// "tcp" or "unix", e.g. SetSyslog("udp", "localhost:514"). Enable it with
+// EnableSyslog. Setting it again keeps the format of the old one.
+func (l *Log) SetSyslog(network, addr string) error {
+	conn, err := net.DialTimeout(network, addr, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	s := &Syslog{w: conn, m: &sync.Mutex{}, format: SyslogFormatText, log: l}
+	l.mu.Lock()
+	old, _ := l.Loggers["syslog"].(io.Closer)
+	if prev, ok := old.(*Syslog); ok {
+		prev.m.Lock()
+		s.format = prev.format
+		prev.m.Unlock()
+	}
+	l.Loggers["syslog"] = s
+	l.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// SetSyslogFormat sets the message body format of the global logger's syslog logger.
+func SetSyslogFormat(format string) error {
+	return logger.SetSyslogFormat(format)
+}
+
+// SetSyslogFormat sets the message body format of the syslog logger,
+// SyslogFormatText or SyslogFormatCEE. The @cee: json of SyslogFormatCEE
+// is the loggly post of the event, which rsyslog and syslog-ng parse into
+// fields.
+func (l *Log) SetSyslogFormat(format string) error {
+	switch format {
+	case SyslogFormatText, SyslogFormatCEE:
+	default:
+		return fmt.Errorf("unknown syslog format %q", format)
+	}
+	l.mu.RLock()
+	s, ok := l.Loggers["syslog"].(*Syslog)
+	l.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("syslog logger not set")
+	}
+	s.m.Lock()
+	s.format = format
+	s.m.Unlock()
+	return nil
+}
+
+// EnableSyslog turns writing to syslog on or off.
+func EnableSyslog(on bool) { logger.EnableSyslog(on) }
+
+// EnableSyslog turns writing to syslog on or off. The syslog logger is
+// created by SetSyslog.
+func (l *Log) EnableSyslog(on bool) {
+	l.enable("syslog", &l.toSyslog, on)
+}
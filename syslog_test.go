@@ -0,0 +1,73 @@
+package plywood
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	read := func() string {
+		buf := make([]byte, 4096)
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	l := New("test", "production", INFO)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	l.SetClock(func() time.Time { return now })
+	if err := l.SetSyslog("udp", pc.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	l.EnableSyslog(true)
+	defer l.Close()
+
+	l.Warning("disk low")
+	header := regexp.MustCompile(`^<12>1 2024-01-02T03:04:05\.006Z \S+ test \d+ - - `)
+	frame := read()
+	if !header.MatchString(frame) || !strings.HasSuffix(frame, "] disk low\n") {
+		t.Errorf("unexpected text frame %q", frame)
+	}
+
+	if err := l.SetSyslogFormat(SyslogFormatCEE); err != nil {
+		t.Fatal(err)
+	}
+	l.Error(map[string]interface{}{"user": "ann"})
+	frame = read()
+	i := strings.Index(frame, "@cee: ")
+	if !strings.HasPrefix(frame, "<11>1 ") || i < 0 {
+		t.Fatalf("expected a cee frame got %q", frame)
+	}
+	var post LogglyPost
+	if err := json.Unmarshal([]byte(frame[i+len("@cee: "):]), &post); err != nil {
+		t.Fatalf("%s: %q", err, frame)
+	}
+	msg, _ := post.Msg.(map[string]interface{})
+	if post.App != "test" || post.Level != "E" || msg["user"] != "ann" {
+		t.Errorf("unexpected cee payload %+v", post)
+	}
+
+	if err := l.SetSyslogFormat("xml"); err == nil {
+		t.Error("expected an unknown format rejected")
+	}
+
+	if err := l.SetSyslog("udp", pc.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("again")
+	if frame = read(); !strings.Contains(frame, "@cee: ") {
+		t.Errorf("expected the cee format kept by SetSyslog got %q", frame)
+	}
+}
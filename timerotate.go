@@ -0,0 +1,170 @@
+package plywood
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TimeRotatingFile implements sender and logs events to a file named after
+// the current period, a new file is opened when the period rolls over.
+type TimeRotatingFile struct {
+	dir      string
+	layout   string // time layout of the file name
+	interval time.Duration
+	keep     int // periods kept, 0 keeps every file
+	log      *Log
+	f        *os.File
+	start    time.Time // period of f
+	m        *sync.Mutex
+}
+
+// newTimeRotatingFile creates a time rotating file sender for pattern
+// without opening it, the periods follow the clock of log.
+func newTimeRotatingFile(log *Log, pattern string, interval time.Duration) *TimeRotatingFile {
+	return &TimeRotatingFile{
+		dir:      filepath.Dir(pattern),
+		layout:   filepath.Base(pattern),
+		interval: interval,
+		log:      log,
+		m:        &sync.Mutex{},
+	}
+}
+
+// now returns the time of the owning logger.
+func (r *TimeRotatingFile) now() time.Time {
+	if r.log == nil {
+		return timeNow()
+	}
+	r.log.mu.RLock()
+	defer r.log.mu.RUnlock()
+	return r.log.now()
+}
+
+// periodStart returns the start of the period holding t. Periods start at
+// midnight in the location of t, an interval of a day or more rotates daily.
+func periodStart(t time.Time, interval time.Duration) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	if interval <= 0 || interval >= 24*time.Hour {
+		return midnight
+	}
+	return midnight.Add(t.Sub(midnight).Truncate(interval))
+}
+
+// open opens the file of the period holding now, closing the previous one
+// and pruning expired files when the period rolled over.
+// The caller must hold r.m.
+func (r *TimeRotatingFile) open(now time.Time) error {
+	start := periodStart(now, r.interval)
+	if r.f != nil && start.Equal(r.start) {
+		return nil
+	}
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+	fh, err := os.OpenFile(filepath.Join(r.dir, start.Format(r.layout)), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	r.f, r.start = fh, start
+	r.prune()
+	return nil
+}
+
+// prune removes the files of periods older than keep periods back.
+// The caller must hold r.m.
+func (r *TimeRotatingFile) prune() {
+	if r.keep < 1 {
+		return
+	}
+	interval := r.interval
+	if interval <= 0 || interval >= 24*time.Hour {
+		interval = 24 * time.Hour
+	}
+	cutoff := r.start.Add(-time.Duration(r.keep-1) * interval)
+	infos, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		t, err := time.ParseInLocation(r.layout, info.Name(), r.start.Location())
+		if err != nil || !t.Before(cutoff) {
+			continue
+		}
+		os.Remove(filepath.Join(r.dir, info.Name()))
+	}
+}
+
+// Send a log event to the file of the current period.
+func (r *TimeRotatingFile) Send(severity, env string, data interface{}) error {
+	d, ok := data.(string)
+	if !ok {
+		return nil
+	}
+	now := r.now()
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.open(now); err != nil {
+		return err
+	}
+	_, err := io.WriteString(r.f, d)
+	return err
+}
+
+// Close closes the underlying file handle. A later Send reopens it.
+func (r *TimeRotatingFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// SetTimeRotatingFile makes the file logger of the global logger rotate by time, see Log.SetTimeRotatingFile.
+func SetTimeRotatingFile(pattern string, interval time.Duration) error {
+	return logger.SetTimeRotatingFile(pattern, interval)
+}
+
+// SetTimeRotatingFile sets the file logger to a new file every interval.
+// The file name of pattern is a time layout formatted with the start of
+// the period, logs/app-2006-01-02.log writes logs/app-2024-01-02.log on
+// that day. Periods follow the clock set by SetClock.
+func (l *Log) SetTimeRotatingFile(pattern string, interval time.Duration) error {
+	r := newTimeRotatingFile(l, pattern, interval)
+	now := r.now()
+	r.m.Lock()
+	err := r.open(now)
+	r.m.Unlock()
+	if err != nil {
+		return err
+	}
+	l.setFile(r)
+	return nil
+}
+
+// SetTimeRotatingFileRetention sets how many periods of files the global logger keeps.
+func SetTimeRotatingFileRetention(keep int) {
+	logger.SetTimeRotatingFileRetention(keep)
+}
+
+// SetTimeRotatingFileRetention keeps the files of the last keep periods of
+// the time rotating file logger, older ones matching the pattern are
+// removed on rotation. 0 keeps every file.
+func (l *Log) SetTimeRotatingFileRetention(keep int) {
+	l.mu.RLock()
+	r, ok := l.Loggers["file"].(*TimeRotatingFile)
+	l.mu.RUnlock()
+	if ok {
+		r.m.Lock()
+		r.keep = keep
+		r.m.Unlock()
+	}
+}
@@ -0,0 +1,66 @@
+package plywood
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plywood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a stale file from long ago and one that is not ours
+	for _, name := range []string{"app-2023-12-01.log", "other.log"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC)
+	l := New("test", "testing", INFO)
+	l.SetClock(func() time.Time { return now })
+	if err := l.SetTimeRotatingFile(filepath.Join(dir, "app-2006-01-02.log"), 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	l.SetTimeRotatingFileRetention(2)
+	l.toFile = true
+	l.Info("before midnight")
+	now = now.Add(2 * time.Minute)
+	l.Info("after midnight")
+	l.Close()
+
+	for name, want := range map[string]string{
+		"app-2024-01-01.log": "] before midnight\n",
+		"app-2024-01-02.log": "] after midnight\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(b), want) || strings.Count(string(b), "\n") != 1 {
+			t.Errorf("%s: unexpected content %q", name, b)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-2023-12-01.log")); !os.IsNotExist(err) {
+		t.Errorf("expected stale file pruned, stat: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.log")); err != nil {
+		t.Errorf("unrelated file removed: %v", err)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 13, 45, 10, 0, time.UTC)
+	if got, want := periodStart(tm, time.Hour), time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("hourly: expected %v got %v", want, got)
+	}
+	if got, want := periodStart(tm, 24*time.Hour), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("daily: expected %v got %v", want, got)
+	}
+}
@@ -0,0 +1,55 @@
+package plywood
+
+import (
+	"context"
+)
+
+// Field names of the trace and span ids.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceExtractor returns the trace and span ids of the span in ctx, empty
+// when there is none. With OpenTelemetry:
+//
+//	func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", ""
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	}
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+// WithTrace returns a child of the global logger adding the trace and span ids to every event.
+func WithTrace(traceID, spanID string) *Log {
+	return logger.WithTrace(traceID, spanID)
+}
+
+// WithTrace returns a child of l adding traceID and spanID to every event
+// as the trace_id and span_id fields, empty ids are left out.
+func (l *Log) WithTrace(traceID, spanID string) *Log {
+	fields := make(map[string]interface{}, 2)
+	if traceID != "" {
+		fields[TraceIDKey] = traceID
+	}
+	if spanID != "" {
+		fields[SpanIDKey] = spanID
+	}
+	return l.WithFields(fields)
+}
+
+// SetTraceExtractor sets how the global logger finds the span of a context, see Log.SetTraceExtractor.
+func SetTraceExtractor(fn TraceExtractor) {
+	logger.SetTraceExtractor(fn)
+}
+
+// SetTraceExtractor sets how the context aware logging functions, like
+// InfoCtx, find the trace and span ids of the span in their context, which
+// are added as WithTrace does. nil stops looking.
+func (l *Log) SetTraceExtractor(fn TraceExtractor) {
+	l.mu.Lock()
+	l.traceExtractor = fn
+	l.mu.Unlock()
+}
@@ -0,0 +1,57 @@
+package plywood
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// spanKey stores a fake span in a context for the trace extractor.
+type spanKey struct{}
+
+func TestWithTrace(t *testing.T) {
+	r := newLogglyRecorder()
+	defer r.Close()
+	var buf bytes.Buffer
+	l := New("test", "production", INFO)
+	useRecorder(l, r)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	l.WithTrace("4bf92f35", "00f067aa").Info("traced")
+	l.SetTraceExtractor(func(ctx context.Context) (string, string) {
+		ids, _ := ctx.Value(spanKey{}).([2]string)
+		return ids[0], ids[1]
+	})
+	l.InfoCtx(context.WithValue(context.Background(), spanKey{}, [2]string{"a1b2", "c3d4"}), "from ctx")
+	l.InfoCtx(context.Background(), "no span")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %q", buf.String())
+	}
+	for i, want := range []string{
+		" span_id=00f067aa trace_id=4bf92f35] traced",
+		" span_id=c3d4 trace_id=a1b2] from ctx",
+	} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("expected %q in %q", want, lines[i])
+		}
+	}
+	if strings.Contains(lines[2], "trace_id") {
+		t.Errorf("unexpected trace in %q", lines[2])
+	}
+
+	posts := r.posts(t)
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts got %d", len(posts))
+	}
+	for i, ids := range [][2]string{{"4bf92f35", "00f067aa"}, {"a1b2", "c3d4"}} {
+		msg, _ := posts[i].Msg.(map[string]interface{})
+		if msg[TraceIDKey] != ids[0] || msg[SpanIDKey] != ids[1] {
+			t.Errorf("post %d: expected ids %v in %v", i, ids, msg)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package plywood
+
+// Webhook implements sender and posts log events as json, in the shape of
+// a LogglyPost, to any url.
+type Webhook struct {
+	poster
+	url string
+	log *Log // owning log, source of the app and host of each event
+}
+
+// Send a log event to the webhook.
+func (w *Webhook) Send(severity, env string, data interface{}) error {
+	w.log.mu.RLock()
+	caller := w.log.callerName(1)
+	w.log.mu.RUnlock()
+	return w.sendCaller(severity, env, caller, data)
+}
+
+// sendCaller sends a log event logged by caller to the webhook.
+func (w *Webhook) sendCaller(severity, env, caller string, data interface{}) error {
+	buf, err := encodePost(w.log, severity, env, caller, data)
+	if err != nil {
+		diag("E " + err.Error() + "] \n")
+		return err
+	}
+	defer putBuffer(buf)
+	return w.post(w.url, buf.Bytes())
+}
+
+// SetWebhook creates the webhook logger posting to url with the extra
+// headers, e.g. an Authorization bearer token. Posts use the loggly retry
+// and timeout settings. Enable it with EnableWebhook or EnableWebhookAsync.
+func SetWebhook(url string, headers map[string]string) {
+	logger.SetWebhook(url, headers)
+}
+
+// SetWebhook creates the webhook logger posting to url with the extra
+// headers, e.g. an Authorization bearer token. Posts use the loggly retry
+// and timeout settings. Enable it with EnableWebhook or EnableWebhookAsync.
+func (l *Log) SetWebhook(url string, headers map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w := &Webhook{
+		poster: newPoster(l),
+		url:    url,
+		log:    l,
+	}
+	w.headers = map[string]string{"Content-Type": "application/json"}
+	for k, v := range headers {
+		w.headers[k] = v
+	}
+	l.Loggers["webhook"] = w
+}
+
+// EnableWebhook turns synchronous webhook posts on or off.
+func EnableWebhook(on bool) { logger.EnableWebhook(on) }
+
+// EnableWebhookAsync turns async webhook posts on or off.
+func EnableWebhookAsync(on bool) { logger.EnableWebhookAsync(on) }
+
+// EnableWebhook turns synchronous webhook posts on or off. The webhook
+// logger is created by SetWebhook.
+func (l *Log) EnableWebhook(on bool) {
+	l.enable("webhook", &l.toWebhook, on)
+}
+
+// EnableWebhookAsync turns async webhook posts on or off. The webhook
+// logger is created by SetWebhook.
+func (l *Log) EnableWebhookAsync(on bool) {
+	l.enable("webhook", &l.toWebhooka, on)
+}
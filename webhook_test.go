@@ -0,0 +1,93 @@
+package plywood
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// webhookRecorder is a fake webhook endpoint recording every posted body
+// and its headers.
+type webhookRecorder struct {
+	*httptest.Server
+	m       sync.Mutex
+	bodies  [][]byte
+	headers []http.Header
+}
+
+func newWebhookRecorder() *webhookRecorder {
+	r := &webhookRecorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		r.m.Lock()
+		r.bodies = append(r.bodies, b)
+		r.headers = append(r.headers, req.Header)
+		r.m.Unlock()
+	}))
+	return r
+}
+
+func TestWebhook(t *testing.T) {
+	r := newWebhookRecorder()
+	defer r.Close()
+	l := New("test", "development", INFO)
+	l.SetWebhook(r.URL, map[string]string{"Authorization": "Bearer secret"})
+	l.EnableWebhook(true)
+
+	l.Debug("hidden")
+	l.Info("hello")
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if len(r.bodies) != 1 {
+		t.Fatalf("expected 1 post got %d", len(r.bodies))
+	}
+	var p LogglyPost
+	if err := json.Unmarshal(r.bodies[0], &p); err != nil {
+		t.Fatalf("%s: %s", err, r.bodies[0])
+	}
+	if p.App != "test" || p.Env != "development" || p.Level != "I" {
+		t.Errorf("unexpected post %+v", p)
+	}
+	if m, ok := p.Msg.(map[string]interface{}); !ok || m["str"] != "hello" {
+		t.Errorf("unexpected msg %v", p.Msg)
+	}
+	if got := r.headers[0].Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("expected the Authorization header got %q", got)
+	}
+	if got := r.headers[0].Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected a json Content-Type got %q", got)
+	}
+}
+
+func TestWebhookAsync(t *testing.T) {
+	r := newWebhookRecorder()
+	defer r.Close()
+	l := New("test", "development", INFO)
+	l.SetWebhook(r.URL, nil)
+	l.EnableWebhookAsync(true)
+
+	for i := 0; i < 5; i++ {
+		l.Info("async")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if len(r.bodies) != 5 {
+		t.Fatalf("expected 5 posts got %d", len(r.bodies))
+	}
+}
+
+func TestWebhookNotSet(t *testing.T) {
+	l := New("test", "development", INFO)
+	l.EnableWebhook(true)
+	if err := l.Info("nowhere"); err != nil {
+		t.Fatal(err)
+	}
+}
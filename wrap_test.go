@@ -0,0 +1,11 @@
+package plywood
+
+// wrapInfo and wrapDeep stand for a package wrapping plywood, the frames
+// of this file are filtered out in TestCallerFilter.
+func wrapInfo(l *Log, msg string) {
+	l.Info(msg)
+}
+
+func wrapDeep(l *Log, msg string) {
+	wrapInfo(l, msg)
+}
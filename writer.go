@@ -0,0 +1,59 @@
+package plywood
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// logWriter is an io.Writer logging each write as a single event.
+type logWriter struct {
+	l     *Log
+	level uint
+}
+
+// Writer returns an io.Writer logging each write as a single event at level
+// on the global logger, e.g. stdlog.SetOutput(log.Writer(log.INFO)).
+func Writer(level uint) io.Writer {
+	return logger.Writer(level)
+}
+
+// Writer returns an io.Writer logging each write as a single event at level.
+// The written bytes are the message as is, minus a single trailing newline;
+// plywood adds its own header so configure a standard library logger
+// writing here with no flags.
+func (l *Log) Writer(level uint) io.Writer {
+	return &logWriter{l: l, level: level}
+}
+
+// writerPackages are the packages writing to a logWriter on behalf of
+// the code logging, their frames are skipped for the caller.
+var writerPackages = map[string]bool{"fmt": true, "log": true, "io": true, "bufio": true}
+
+// Write logs p as one event, its caller is the first frame past the
+// standard library writing it, e.g. the caller of log.Println. It is safe
+// for concurrent use as the senders serialize their own writes.
+func (w *logWriter) Write(p []byte) (int, error) {
+	if !w.l.Enabled(w.level) {
+		return len(p), nil
+	}
+	msg := strings.TrimSuffix(string(p), "\n")
+	w.l.mu.RLock()
+	caller := w.l.callerSkipping(1, func(f runtime.Frame) bool { return writerPackages[funcPackage(f.Function)] })
+	w.l.mu.RUnlock()
+	if err := w.l.send(context.Background(), w.level, "%s", []interface{}{msg}, false, caller); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// funcPackage returns the import path of the package of the function
+// named fn, e.g. log for log.(*Logger).output.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/") + 1
+	if dot := strings.Index(fn[slash:], "."); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
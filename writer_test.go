@@ -0,0 +1,43 @@
+package plywood
+
+import (
+	"bytes"
+	"fmt"
+	stdlog "log"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("test", "testing", INFO)
+	l.Loggers["stderr"] = &Console{w: &buf, m: &sync.Mutex{}}
+	l.toStderr = true
+
+	_, _, line, _ := runtime.Caller(0)
+	stdlog.New(l.Writer(INFO), "", 0).Println("hi")
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 {
+		t.Fatalf("expected a single event got %q", out)
+	}
+	if !strings.HasPrefix(out, "I") || !strings.HasSuffix(out, "] hi\n") {
+		t.Errorf("unexpected event %q", out)
+	}
+	if want := fmt.Sprintf(" writer_test.go:%d:", line+1); !strings.Contains(out, want) || !strings.Contains(out, "TestWriter]") {
+		t.Errorf("expected the caller of Println%s got %q", want, out)
+	}
+
+	buf.Reset()
+	fmt.Fprintln(l.Writer(INFO), "fprint")
+	if want := fmt.Sprintf(" writer_test.go:%d:", line+14); !strings.Contains(buf.String(), want) {
+		t.Errorf("expected the caller of Fprintln%s got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	l.Writer(DEBUG).Write([]byte("hidden\n"))
+	if buf.Len() != 0 {
+		t.Errorf("debug write logged %q", buf.String())
+	}
+}